@@ -0,0 +1,160 @@
+package charset
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// DefaultCharset is assumed when neither a byte order mark nor a <meta> tag declares the charset.
+const DefaultCharset = "utf-8"
+
+// prescanLength is the number of leading bytes looked through for the charset declaration.
+const prescanLength = 1024
+
+var ErrUnknownCharset = errors.New("unknown charset")
+
+var boms = []struct {
+	bom     []byte
+	charset string
+}{
+	{[]byte{0xef, 0xbb, 0xbf}, "utf-8"},
+	{[]byte{0xfe, 0xff}, "utf-16be"},
+	{[]byte{0xff, 0xfe}, "utf-16le"},
+}
+
+// Lookup returns the encoding and the canonical name of the charset label, e.g. "cp1251" or
+// "koi8-r". The encoding is nil for unknown labels.
+func Lookup(label string) (e encoding.Encoding, name string) {
+	return charset.Lookup(label)
+}
+
+// NewReader returns the reader transcoding the HTML document to UTF-8 and the canonical name of
+// its charset. When the label is empty, the charset is detected from the byte order mark or the
+// <meta> tag in the beginning of the document, falling back to DefaultCharset.
+func NewReader(r io.Reader, label string) (_ io.Reader, name string, err error) {
+	return newReader(r, label, true)
+}
+
+// NewTextReader is NewReader for the documents without markup, e.g. JSON, whose charset is only
+// detected from the byte order mark.
+func NewTextReader(r io.Reader, label string) (_ io.Reader, name string, err error) {
+	return newReader(r, label, false)
+}
+
+func newReader(r io.Reader, label string, markup bool) (_ io.Reader, name string, err error) {
+	br := bufio.NewReaderSize(r, prescanLength)
+
+	head, err := br.Peek(prescanLength)
+	if err != nil && err != io.EOF {
+		return nil, "", err
+	}
+
+	var e encoding.Encoding
+
+	if label != "" {
+		if e, name = Lookup(label); e == nil {
+			return nil, "", ErrUnknownCharset
+		}
+	} else {
+		e, name = detect(head, markup)
+	}
+
+	if name == DefaultCharset && !hasBOM(head) {
+		return br, name, nil
+	}
+
+	return transform.NewReader(br, unicode.BOMOverride(e.NewDecoder())), name, nil
+}
+
+func detect(head []byte, markup bool) (e encoding.Encoding, name string) {
+	for _, b := range boms {
+		if bytes.HasPrefix(head, b.bom) {
+			return Lookup(b.charset)
+		}
+	}
+
+	if !markup {
+		return Lookup(DefaultCharset)
+	}
+
+	if e, name = prescan(head); e != nil {
+		return e, name
+	}
+
+	return Lookup(DefaultCharset)
+}
+
+func hasBOM(head []byte) bool {
+	for _, b := range boms {
+		if bytes.HasPrefix(head, b.bom) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// prescan looks for <meta charset="..."> and <meta http-equiv="content-type" content="...">.
+func prescan(head []byte) (e encoding.Encoding, name string) {
+	z := html.NewTokenizer(bytes.NewReader(head))
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return nil, ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tagName, hasAttr := z.TagName()
+			if !bytes.Equal(tagName, []byte("meta")) || !hasAttr {
+				continue
+			}
+
+			if e, name = metaCharset(z); e != nil {
+				return e, name
+			}
+		}
+	}
+}
+
+func metaCharset(z *html.Tokenizer) (e encoding.Encoding, name string) {
+	var (
+		contentType string
+		httpEquiv   bool
+	)
+
+	for {
+		key, val, more := z.TagAttr()
+
+		switch string(key) {
+		case "charset":
+			return Lookup(string(val))
+		case "http-equiv":
+			httpEquiv = strings.EqualFold(string(val), "content-type")
+		case "content":
+			contentType = string(val)
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	if !httpEquiv {
+		return nil, ""
+	}
+
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		return Lookup(params["charset"])
+	}
+
+	return nil, ""
+}
@@ -0,0 +1,173 @@
+package charset
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReader(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		content []byte
+		label   string
+
+		wantErr         bool
+		expectedCharset string
+		expected        string
+	}{
+		{
+			name:    "utf-8 by default",
+			enabled: true,
+
+			content: []byte(`<li>Привет</li>`),
+
+			expectedCharset: "utf-8",
+			expected:        `<li>Привет</li>`,
+		},
+		{
+			name:    "utf-8 byte order mark",
+			enabled: true,
+
+			content: append([]byte{0xef, 0xbb, 0xbf}, `<li>Привет</li>`...),
+
+			expectedCharset: "utf-8",
+			expected:        `<li>Привет</li>`,
+		},
+		{
+			name:    "utf-16le byte order mark",
+			enabled: true,
+
+			content: []byte{0xff, 0xfe, '<', 0, 'b', 0, '>', 0, 0x1f, 0x04, '<', 0, '/', 0, 'b', 0, '>', 0},
+
+			expectedCharset: "utf-16le",
+			expected:        `<b>П</b>`,
+		},
+		{
+			name:    "meta charset",
+			enabled: true,
+
+			content: []byte("<meta charset=\"windows-1251\"><li>\xcf\xf0\xe8\xe2\xe5\xf2</li>"),
+
+			expectedCharset: "windows-1251",
+			expected:        `<meta charset="windows-1251"><li>Привет</li>`,
+		},
+		{
+			name:    "meta http-equiv content type",
+			enabled: true,
+
+			content: []byte("<meta http-equiv=\"Content-Type\" content=\"text/html; charset=koi8-r\">" +
+				"<li>\xf0\xd2\xc9\xd7\xc5\xd4</li>"),
+
+			expectedCharset: "koi8-r",
+			expected:        `<meta http-equiv="Content-Type" content="text/html; charset=koi8-r"><li>Привет</li>`,
+		},
+		{
+			name:    "label overrides meta charset",
+			enabled: true,
+
+			content: []byte("<meta charset=\"utf-8\"><li>\xcf\xf0\xe8\xe2\xe5\xf2</li>"),
+			label:   "cp1251",
+
+			expectedCharset: "windows-1251",
+			expected:        `<meta charset="utf-8"><li>Привет</li>`,
+		},
+		{
+			name:    "unknown label",
+			enabled: true,
+
+			content: []byte(`<li>blabla</li>`),
+			label:   "klingon",
+
+			wantErr: true,
+		},
+		{
+			name:    "empty content",
+			enabled: true,
+
+			expectedCharset: "utf-8",
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			r, actualCharset, err := NewReader(bytes.NewReader(test.content), test.label)
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			actual, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, test.expectedCharset, actualCharset)
+			assert.Equal(t, test.expected, string(actual))
+		})
+	}
+}
+
+func TestNewTextReader(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		content []byte
+
+		expectedCharset string
+		expected        string
+	}{
+		{
+			name:    "meta charset is ignored",
+			enabled: true,
+
+			content: []byte(`{"html": "<meta charset=windows-1251>", "title": "Привет"}`),
+
+			expectedCharset: "utf-8",
+			expected:        `{"html": "<meta charset=windows-1251>", "title": "Привет"}`,
+		},
+		{
+			name:    "utf-16be byte order mark",
+			enabled: true,
+
+			content: []byte{0xfe, 0xff, 0, '"', 0x04, 0x1f, 0, '"'},
+
+			expectedCharset: "utf-16be",
+			expected:        `"П"`,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			r, actualCharset, err := NewTextReader(bytes.NewReader(test.content), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := ioutil.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, test.expectedCharset, actualCharset)
+			assert.Equal(t, test.expected, string(actual))
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+)
+
+const (
+	maxHostnameLength = 253
+	// maxSocketPathLength is the size of sun_path without the terminating NUL.
+	maxSocketPathLength = 107
+)
+
+var (
+	ErrMissingPort     = fmt.Errorf("%w: missing port", ErrInvalidAddress)
+	ErrInvalidPort     = fmt.Errorf("%w: port must be a number between 1 and 65535", ErrInvalidAddress)
+	ErrInvalidHost     = fmt.Errorf("%w: invalid host", ErrInvalidAddress)
+	ErrUnbracketedIPv6 = fmt.Errorf("%w: ipv6 address must be enclosed in brackets", ErrInvalidAddress)
+	ErrInvalidZone     = fmt.Errorf("%w: zone is allowed for ipv6 addresses only", ErrInvalidAddress)
+	ErrInvalidURL      = fmt.Errorf("%w: invalid url", ErrInvalidAddress)
+	ErrInvalidSocket   = fmt.Errorf("%w: invalid unix socket path", ErrInvalidAddress)
+	ErrInvalidPath     = fmt.Errorf("%w: invalid file path", ErrInvalidAddress)
+)
+
+// Address is the parsed form of Input.Address. For files and Unix sockets only Scheme and Path are
+// filled, for the custom transports only Scheme and Host, holding the rest of the address.
+type Address struct {
+	Scheme string
+	Host   string
+	Zone   string
+	Port   int
+	Path   string
+}
+
+// ParseAddress parses the address of the transport selected by its scheme: "host:port" for TCP
+// and TLS, where the host is a hostname, an IPv4 or a bracketed IPv6 address with an optional
+// zone, an absolute URL for HTTP(S), the socket path for Unix sockets, where a leading "@"
+// denotes the Linux abstract namespace, and the path relative to the root directory for files.
+func ParseAddress(s string) (addr Address, err error) {
+	if s == "" {
+		return addr, ErrEmptyAddress
+	}
+
+	scheme, rest := ahp.SplitAddress(s)
+
+	switch scheme {
+	case "tcp", "tls":
+		addr, err = parseHostPort(rest)
+	case "http", "https":
+		addr, err = parseURL(s)
+	case "unix", "unixpacket":
+		addr, err = parseSocketPath(rest)
+	case "file":
+		if rest == "" || strings.IndexByte(rest, 0) >= 0 {
+			return addr, ErrInvalidPath
+		}
+
+		addr.Path = rest
+	default:
+		// Addresses of custom transports are validated by their downloaders.
+		if rest == "" {
+			return addr, ErrInvalidAddress
+		}
+
+		addr.Host = rest
+	}
+
+	addr.Scheme = scheme
+
+	return addr, err
+}
+
+func validateAddress(s string) (err error) {
+	_, err = ParseAddress(s)
+
+	return err
+}
+
+func parseHostPort(s string) (addr Address, err error) {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		if !strings.HasPrefix(s, "[") && strings.Count(s, ":") > 1 {
+			return addr, ErrUnbracketedIPv6
+		}
+
+		if !strings.HasSuffix(s, "]") && strings.Contains(s, ":") {
+			return addr, ErrInvalidHost
+		}
+
+		return addr, ErrMissingPort
+	}
+
+	if addr.Port, err = parsePort(port); err != nil {
+		return addr, err
+	}
+
+	addr.Host, addr.Zone, err = parseHost(host, strings.HasPrefix(s, "["))
+
+	return addr, err
+}
+
+func parseURL(s string) (addr Address, err error) {
+	u, err := url.Parse(s)
+	if err != nil || !u.IsAbs() || u.Host == "" || u.Opaque != "" {
+		return addr, ErrInvalidURL
+	}
+
+	if port := u.Port(); port != "" || strings.HasSuffix(u.Host, ":") {
+		if addr.Port, err = parsePort(port); err != nil {
+			return addr, err
+		}
+	}
+
+	addr.Host, addr.Zone, err = parseHost(u.Hostname(), strings.HasPrefix(u.Host, "["))
+
+	return addr, err
+}
+
+func parseSocketPath(s string) (addr Address, err error) {
+	if s == "" || s == "@" || len(s) > maxSocketPathLength || strings.IndexByte(s, 0) >= 0 {
+		return addr, ErrInvalidSocket
+	}
+
+	addr.Path = s
+
+	return addr, nil
+}
+
+func parsePort(s string) (port int, err error) {
+	port, err = strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, ErrInvalidPort
+	}
+
+	return port, nil
+}
+
+func parseHost(s string, bracketed bool) (host, zone string, err error) {
+	host = s
+
+	if i := strings.LastIndexByte(s, '%'); i >= 0 {
+		host, zone = s[:i], s[i+1:]
+
+		if zone == "" || !bracketed {
+			return "", "", ErrInvalidZone
+		}
+	}
+
+	if bracketed {
+		if ip := net.ParseIP(host); ip == nil || !strings.Contains(host, ":") {
+			return "", "", ErrInvalidHost
+		}
+
+		return host, zone, nil
+	}
+
+	if isDottedNumeric(host) {
+		if ip := net.ParseIP(host); ip == nil {
+			return "", "", ErrInvalidHost
+		}
+
+		return host, "", nil
+	}
+
+	if !isHostname(host) {
+		return "", "", ErrInvalidHost
+	}
+
+	return host, "", nil
+}
+
+func isDottedNumeric(s string) bool {
+	for _, r := range s {
+		if r != '.' && (r < '0' || r > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isHostname reports whether s consists of dot separated labels of letters, digits, hyphens and
+// underscores, neither starting nor ending with a hyphen.
+func isHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > maxHostnameLength {
+		return false
+	}
+
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+
+		for _, r := range label {
+			isAlnum := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+			if !isAlnum && r != '-' && r != '_' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		address string
+
+		wantErr       bool
+		expectedErr   error
+		expectedValue Address
+	}{
+		{
+			name:    "ipv4",
+			enabled: true,
+
+			address: "127.0.0.1:8080",
+
+			expectedValue: Address{Scheme: "tcp", Host: "127.0.0.1", Port: 8080},
+		},
+		{
+			name:    "hostname with scheme",
+			enabled: true,
+
+			address: "tls://my-domain.zone.:8443",
+
+			expectedValue: Address{Scheme: "tls", Host: "my-domain.zone.", Port: 8443},
+		},
+		{
+			name:    "bracketed ipv6",
+			enabled: true,
+
+			address: "[::1]:8080",
+
+			expectedValue: Address{Scheme: "tcp", Host: "::1", Port: 8080},
+		},
+		{
+			name:    "bracketed ipv6 with zone",
+			enabled: true,
+
+			address: "[fe80::1%eth0]:9000",
+
+			expectedValue: Address{Scheme: "tcp", Host: "fe80::1", Zone: "eth0", Port: 9000},
+		},
+		{
+			name:    "unix socket",
+			enabled: true,
+
+			address: "unix:///var/run/renderer.sock",
+
+			expectedValue: Address{Scheme: "unix", Path: "/var/run/renderer.sock"},
+		},
+		{
+			name:    "abstract unixpacket socket",
+			enabled: true,
+
+			address: "unixpacket://@renderer",
+
+			expectedValue: Address{Scheme: "unixpacket", Path: "@renderer"},
+		},
+		{
+			name:    "file",
+			enabled: true,
+
+			address: "file:///pages/index.html",
+
+			expectedValue: Address{Scheme: "file", Path: "/pages/index.html"},
+		},
+		{
+			name:    "url without port",
+			enabled: true,
+
+			address: "https://mydomain.zone/page?id=42",
+
+			expectedValue: Address{Scheme: "https", Host: "mydomain.zone"},
+		},
+		{
+			name:    "url with ipv6 and zone",
+			enabled: true,
+
+			address: "http://[fe80::1%25eth0]:8080/page",
+
+			expectedValue: Address{Scheme: "http", Host: "fe80::1", Zone: "eth0", Port: 8080},
+		},
+		{
+			name:    "custom scheme",
+			enabled: true,
+
+			address: "custom://anything",
+
+			expectedValue: Address{Scheme: "custom", Host: "anything"},
+		},
+		{
+			name:    "empty",
+			enabled: true,
+
+			wantErr:     true,
+			expectedErr: ErrEmptyAddress,
+		},
+		{
+			name:    "missing port",
+			enabled: true,
+
+			address: "mydomain.zone",
+
+			wantErr:     true,
+			expectedErr: ErrMissingPort,
+		},
+		{
+			name:    "missing port of bracketed ipv6",
+			enabled: true,
+
+			address: "[::1]",
+
+			wantErr:     true,
+			expectedErr: ErrMissingPort,
+		},
+		{
+			name:    "empty port",
+			enabled: true,
+
+			address: "mydomain.zone:",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidPort,
+		},
+		{
+			name:    "zero port",
+			enabled: true,
+
+			address: "mydomain.zone:0",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidPort,
+		},
+		{
+			name:    "out of range port",
+			enabled: true,
+
+			address: "mydomain.zone:65536",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidPort,
+		},
+		{
+			name:    "unbracketed ipv6",
+			enabled: true,
+
+			address: "fe80::1:8080",
+
+			wantErr:     true,
+			expectedErr: ErrUnbracketedIPv6,
+		},
+		{
+			name:    "bracketed ipv4",
+			enabled: true,
+
+			address: "[127.0.0.1]:8080",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidHost,
+		},
+		{
+			name:    "zone of ipv4",
+			enabled: true,
+
+			address: "127.0.0.1%eth0:8080",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidZone,
+		},
+		{
+			name:    "empty zone",
+			enabled: true,
+
+			address: "[fe80::1%]:8080",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidZone,
+		},
+		{
+			name:    "invalid ipv4",
+			enabled: true,
+
+			address: "256.789.320.752:8080",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidHost,
+		},
+		{
+			name:    "label starting with hyphen",
+			enabled: true,
+
+			address: "-mydomain.zone:8080",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidHost,
+		},
+		{
+			name:    "empty label",
+			enabled: true,
+
+			address: "mydomain..zone:8080",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidHost,
+		},
+		{
+			name:    "url without host",
+			enabled: true,
+
+			address: "http:///page",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidURL,
+		},
+		{
+			name:    "url with invalid port",
+			enabled: true,
+
+			address: "https://mydomain.zone:99999/page",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidPort,
+		},
+		{
+			name:    "url with invalid host",
+			enabled: true,
+
+			address: "https://my_domain!.zone/page",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidHost,
+		},
+		{
+			name:    "empty custom address",
+			enabled: true,
+
+			address: "custom://",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidAddress,
+		},
+		{
+			name:    "empty socket path",
+			enabled: true,
+
+			address: "unix://",
+
+			wantErr:     true,
+			expectedErr: ErrInvalidSocket,
+		},
+		{
+			name:    "too long socket path",
+			enabled: true,
+
+			address: "unix:///" + strings.Repeat("a", 107),
+
+			wantErr:     true,
+			expectedErr: ErrInvalidSocket,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actual, err := ParseAddress(test.address)
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if test.wantErr {
+				assert.Equal(t, test.expectedErr, err)
+
+				return
+			}
+
+			assert.Equal(t, test.expectedValue, actual)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package cli
+
+import (
+	"bytes"
+	"time"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+)
+
+// contentDownloader hands the content carried by the input to the callback.
+type contentDownloader []byte
+
+func (c contentDownloader) Download(_ int64, _ time.Duration, callbackFn ahp.DownloadCallback) (err error) {
+	return callbackFn(bytes.NewReader(c))
+}
@@ -1,9 +1,7 @@
 package cli
 
 import (
-	"time"
-
 	ahp "github.com/morozovcookie/afihtmlparser"
 )
 
-type DownloaderCreator func(address string, timeout time.Duration) (downloader ahp.Downloader)
+type DownloaderCreator func(cfg ahp.DownloaderConfig) (downloader ahp.Downloader, err error)
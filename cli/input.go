@@ -3,13 +3,20 @@ package cli
 import (
 	"encoding/json"
 	"errors"
-	"regexp"
+	"fmt"
 	"time"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/morozovcookie/afihtmlparser/charset"
+	"github.com/morozovcookie/afihtmlparser/decompress"
+	"github.com/morozovcookie/afihtmlparser/failover"
+	"github.com/morozovcookie/afihtmlparser/xpath"
 )
 
 const (
-	DefaultDialTimeout = Duration(time.Second)
-	DefaultReadTimeout = Duration(time.Second)
+	DefaultDialTimeout  = Duration(time.Second)
+	DefaultReadTimeout  = Duration(time.Second)
+	DefaultWriteTimeout = Duration(time.Second)
 )
 
 var ErrInvalidDuration = errors.New("invalid duration")
@@ -51,57 +58,493 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 }
 
 type Input struct {
-	ContentLength   int64    `json:"content-length"`
-	Address         string   `json:"address"`
-	XPathExpression string   `json:"xpath-expression"`
-	DialTimeout     Duration `json:"dial-timeout"`
-	ReadTimeout     Duration `json:"read-timeout"`
+	ContentLength    int64                 `json:"content-length"`
+	MaxContentLength int64                 `json:"max-content-length"`
+	ReadMode         ahp.ReadMode          `json:"read-mode"`
+	Framing          ahp.Framing           `json:"framing"`
+	Delimiter        string                `json:"delimiter"`
+	Address          string                `json:"address"`
+	Addresses        []string              `json:"addresses"`
+	AddressOrder     failover.Order        `json:"address-order"`
+	SRV              *SRV                  `json:"srv"`
+	XPathExpression  string                `json:"xpath-expression"`
+	SelectorType     ahp.SelectorType      `json:"selector-type"`
+	Expression       string                `json:"expression"`
+	Fields           map[string]string     `json:"fields"`
+	Transforms       Transforms            `json:"transforms"`
+	FieldTransforms  map[string]Transforms `json:"field-transforms"`
+	Schema           *xpath.Schema         `json:"schema"`
+	RenderMode       ahp.RenderMode        `json:"render-mode"`
+	EscapeHTML       bool                  `json:"escape-html"`
+	Charset          string                `json:"charset"`
+	DocumentType     ahp.DocumentType      `json:"document-type"`
+	Namespaces       map[string]string     `json:"namespaces"`
+	DialTimeout      Duration              `json:"dial-timeout"`
+	ReadTimeout      Duration              `json:"read-timeout"`
+	Deadline         Duration              `json:"deadline"`
+	Buffered         bool                  `json:"buffered"`
+	TLS              *TLS                  `json:"tls"`
+	Retry            *Retry                `json:"retry"`
+
+	Content       string        `json:"content"`
+	ContentFormat PayloadFormat `json:"content-format"`
+
+	ContentEncoding     decompress.Encoding `json:"content-encoding"`
+	MaxDecompressedSize int64               `json:"max-decompressed-size"`
+
+	RequestPayload       string        `json:"request-payload"`
+	RequestPayloadFormat PayloadFormat `json:"request-payload-format"`
+	WriteTimeout         Duration      `json:"write-timeout"`
 }
 
 var (
-	ErrZeroContentLengthValue = errors.New("input validation error: zero content-length value")
-	ErrEmptyAddress           = errors.New("input validation error: empty address")
-	ErrInvalidAddress         = errors.New("input validation error: invalid address")
-	ErrEmptyXPathExpression   = errors.New("input validation error: empty xpath expression")
+	ErrZeroContentLengthValue    = errors.New("input validation error: zero content-length value")
+	ErrZeroMaxContentLengthValue = errors.New("input validation error: zero max-content-length value")
+	ErrInvalidReadMode           = errors.New("input validation error: invalid read mode")
+	ErrInvalidFraming            = errors.New("input validation error: invalid framing")
+	ErrFramingWithEOFReadMode    = errors.New("input validation error: framing is not compatible with eof read mode")
+	ErrEmptyDelimiter            = errors.New("input validation error: empty delimiter")
+	ErrEmptyAddress              = errors.New("input validation error: empty address")
+	ErrInvalidAddress            = errors.New("input validation error: invalid address")
+	ErrInvalidAddressOrder       = errors.New("input validation error: invalid address order")
+	ErrRequestPayloadWithoutTCP  = errors.New("input validation error: request-payload requires tcp, tls or unix address")
+	ErrFramingWithHTTP           = errors.New("input validation error: framing is not compatible with http address")
+	ErrBufferedWithHTTP          = errors.New("input validation error: buffered is not compatible with http address")
+	ErrEmptyXPathExpression      = errors.New("input validation error: empty xpath expression")
+	ErrEmptyExpression           = errors.New("input validation error: empty expression")
+	ErrInvalidSelectorType       = errors.New("input validation error: invalid selector type")
+	ErrEmptyFieldName            = errors.New("input validation error: empty field name")
+	ErrEmptyFieldExpression      = errors.New("input validation error: empty field expression")
+	ErrSchemaWithoutXPath        = errors.New("input validation error: schema requires xpath selector type")
+	ErrSchemaWithExpression      = errors.New("input validation error: schema is not compatible with expressions")
+	ErrSchemaWithRenderMode      = errors.New("input validation error: schema is not compatible with render mode")
+	ErrEmptySchemaRoot           = errors.New("input validation error: empty schema root")
+	ErrInvalidRenderMode         = errors.New("input validation error: invalid render mode")
+	ErrUnsupportedRenderMode     = errors.New("input validation error: render mode is not supported by selector type")
+	ErrEscapeHTMLWithoutHTML     = errors.New("input validation error: escape-html requires html document type")
+	ErrInvalidDocumentType       = errors.New("input validation error: invalid document type")
+	ErrXMLWithoutXPath           = errors.New("input validation error: xml document type requires xpath selector type")
+	ErrXMLWithCharset            = errors.New("input validation error: charset of xml documents is declared by them")
+	ErrNamespacesWithHTML        = errors.New("input validation error: namespaces require xml document type")
+	ErrInvalidNamespace          = errors.New("input validation error: invalid namespace binding")
+	ErrInvalidRequestPayload     = errors.New("input validation error: invalid request payload")
+	ErrInvalidContent            = errors.New("input validation error: invalid content")
+	ErrContentWithAddress        = errors.New("input validation error: content is not compatible with address")
+	ErrContentWithConnection     = errors.New("input validation error: content is not compatible with connection settings")
+	ErrInvalidContentEncoding    = errors.New("input validation error: invalid content encoding")
+	ErrZeroMaxDecompressedSize   = errors.New("input validation error: zero max-decompressed-size value")
+	ErrInvalidCharset            = errors.New("input validation error: invalid charset")
 )
 
 func (i Input) Validate() (err error) {
-	if i.ContentLength <= 0 {
-		return ErrZeroContentLengthValue
+	if err = i.validateContentEncoding(); err != nil {
+		return err
 	}
 
-	if err = validateAddress(i.Address); err != nil {
+	if i.Charset != "" {
+		if e, _ := charset.Lookup(i.Charset); e == nil {
+			return ErrInvalidCharset
+		}
+	}
+
+	if err = i.validateDocumentType(); err != nil {
 		return err
 	}
 
-	if i.XPathExpression == "" {
-		return ErrEmptyXPathExpression
+	if i.Inline() {
+		return i.validateContent()
+	}
+
+	if err = i.validateReadMode(); err != nil {
+		return err
+	}
+
+	if err = i.validateFraming(); err != nil {
+		return err
+	}
+
+	if err = i.validateAddresses(); err != nil {
+		return err
+	}
+
+	if err = i.validateTransportOptions(); err != nil {
+		return err
+	}
+
+	if err = i.validateExpression(); err != nil {
+		return err
+	}
+
+	if _, err = i.RequestPayloadFormat.Decode(i.RequestPayload); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequestPayload, err)
+	}
+
+	if i.TLS != nil {
+		if err = i.validateTLS(); err != nil {
+			return err
+		}
+	}
+
+	if i.Retry != nil {
+		return i.Retry.Validate()
 	}
 
 	return nil
 }
 
-const (
-	HostPortRegex = `(?m)^((((25[0-5])|(2[0-4]\d{1})|([0-1]?\d{1,2}))\.){3}((25[0-5])|(2[0-4]\d{1})|` +
-		`([0-1]?\d{1,2})){1}(:((6553[0-5])|(655[0-2]\d{1})|(65[0-4]\d{2})|(6[0-4]\d{3})|([1-5]\d{4})|` +
-		`([1-9]\d{3})|([1-9]\d{2})|([1-9]\d{1})|([1-9])))?)$`
-	HostnamePortRegex = `(?m)^(((([\d\w]|[\d\w][\d\w\-]*[\d\w])\.)*([\d\w]|[\d\w][\d\w\-]*[\d\w]))` +
-		`(:((6553[0-5])|(655[0-2]\d{1})|(65[0-4]\d{2})|(6[0-4]\d{3})|([1-5]\d{4})|([1-9]\d{3})|` +
-		`([1-9]\d{2})|([1-9]\d{1})|([1-9])))?)$`
-)
+func (i Input) validateContentEncoding() (err error) {
+	switch i.ContentEncoding {
+	case decompress.EncodingNone:
+		return nil
+	case decompress.EncodingGzip, decompress.EncodingDeflate, decompress.EncodingZlib, decompress.EncodingAuto:
+	default:
+		return ErrInvalidContentEncoding
+	}
 
-func validateAddress(s string) (err error) {
-	if s == "" {
-		return ErrEmptyAddress
+	if i.MaxDecompressedSize <= 0 {
+		return ErrZeroMaxDecompressedSize
+	}
+
+	return nil
+}
+
+func (i Input) validateDocumentType() (err error) {
+	switch i.DocumentType {
+	case "", ahp.DocumentTypeHTML:
+		if len(i.Namespaces) != 0 {
+			return ErrNamespacesWithHTML
+		}
+
+		return nil
+	case ahp.DocumentTypeXML:
+	default:
+		return ErrInvalidDocumentType
+	}
+
+	if i.SelectorType != "" && i.SelectorType != ahp.SelectorTypeXPath {
+		return ErrXMLWithoutXPath
+	}
+
+	if i.Charset != "" {
+		return ErrXMLWithCharset
+	}
+
+	for prefix, uri := range i.Namespaces {
+		if prefix == "" || uri == "" {
+			return ErrInvalidNamespace
+		}
+	}
+
+	return nil
+}
+
+func (i Input) validateExpression() (err error) {
+	switch i.SelectorType {
+	case "", ahp.SelectorTypeXPath, ahp.SelectorTypeCSS, ahp.SelectorTypeJSONPath:
+	default:
+		return ErrInvalidSelectorType
+	}
+
+	if err = i.validateRenderMode(); err != nil {
+		return err
+	}
+
+	if i.Schema != nil {
+		return i.validateSchema()
+	}
+
+	if err = i.validateTransforms(); err != nil {
+		return err
 	}
 
-	if ok := regexp.MustCompile(HostPortRegex).MatchString(s); ok {
+	for name, expression := range i.Fields {
+		if name == "" {
+			return ErrEmptyFieldName
+		}
+
+		if expression == "" {
+			return ErrEmptyFieldExpression
+		}
+	}
+
+	if i.ParserExpression() != "" || len(i.Fields) != 0 {
 		return nil
 	}
 
-	if ok := regexp.MustCompile(HostnamePortRegex).MatchString(s); ok {
+	if i.SelectorType == "" || i.SelectorType == ahp.SelectorTypeXPath {
+		return ErrEmptyXPathExpression
+	}
+
+	return ErrEmptyExpression
+}
+
+func (i Input) validateRenderMode() (err error) {
+	if i.EscapeHTML && (i.SelectorType == ahp.SelectorTypeJSONPath || i.DocumentType == ahp.DocumentTypeXML) {
+		return ErrEscapeHTMLWithoutHTML
+	}
+
+	switch i.RenderMode {
+	case "":
 		return nil
+	case ahp.RenderModeOuterHTML, ahp.RenderModeInnerHTML, ahp.RenderModeText:
+	case ahp.RenderModeAttribute:
+		if i.SelectorType == ahp.SelectorTypeCSS {
+			return ErrUnsupportedRenderMode
+		}
+	default:
+		return ErrInvalidRenderMode
+	}
+
+	if i.SelectorType == ahp.SelectorTypeJSONPath {
+		return ErrUnsupportedRenderMode
+	}
+
+	return nil
+}
+
+func (i Input) validateTransforms() (err error) {
+	if err = i.Transforms.Validate(); err != nil {
+		return err
+	}
+
+	for name, tt := range i.FieldTransforms {
+		if _, ok := i.Fields[name]; !ok {
+			return ErrTransformsOfUnknownField
+		}
+
+		if err = tt.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (i Input) validateSchema() (err error) {
+	if i.SelectorType != "" && i.SelectorType != ahp.SelectorTypeXPath {
+		return ErrSchemaWithoutXPath
+	}
+
+	if i.ParserExpression() != "" || len(i.Fields) != 0 || len(i.Transforms) != 0 || len(i.FieldTransforms) != 0 {
+		return ErrSchemaWithExpression
+	}
+
+	if i.RenderMode != "" || i.EscapeHTML {
+		return ErrSchemaWithRenderMode
+	}
+
+	if i.Schema.Root == "" {
+		return ErrEmptySchemaRoot
+	}
+
+	return validateSchemaFields(i.Schema.Fields)
+}
+
+func validateSchemaFields(fields map[string]xpath.Field) (err error) {
+	for name, f := range fields {
+		if name == "" {
+			return ErrEmptyFieldName
+		}
+
+		if f.Expression == "" {
+			return ErrEmptyFieldExpression
+		}
+
+		if err = validateSchemaFields(f.Fields); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (i Input) validateContent() (err error) {
+	if i.Address != "" || len(i.Addresses) != 0 || i.SRV != nil {
+		return ErrContentWithAddress
+	}
+
+	if i.TLS != nil || i.Retry != nil || i.RequestPayload != "" || i.WriteTimeout != 0 || i.AddressOrder != "" {
+		return ErrContentWithConnection
+	}
+
+	if i.ReadMode != "" || i.Framing != ahp.FramingNone || i.Delimiter != "" || i.ContentLength != 0 ||
+		i.MaxContentLength != 0 || i.Buffered {
+		return ErrContentWithConnection
+	}
+
+	if err = i.validateExpression(); err != nil {
+		return err
+	}
+
+	if _, err = i.ContentFormat.Decode(i.Content); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidContent, err)
+	}
+
+	return nil
+}
+
+func (i Input) validateReadMode() (err error) {
+	switch i.ReadMode {
+	case "", ahp.ReadModeFixed:
+		if i.Framing != ahp.FramingNone {
+			return nil
+		}
+
+		if i.ContentLength <= 0 {
+			return ErrZeroContentLengthValue
+		}
+	case ahp.ReadModeEOF:
+		if i.MaxContentLength <= 0 {
+			return ErrZeroMaxContentLengthValue
+		}
+	default:
+		return ErrInvalidReadMode
+	}
+
+	return nil
+}
+
+func (i Input) validateFraming() (err error) {
+	switch i.Framing {
+	case ahp.FramingNone:
+		return nil
+	case ahp.FramingUint16, ahp.FramingUint32, ahp.FramingVarint:
+	case ahp.FramingDelimiter:
+		if i.Delimiter == "" {
+			return ErrEmptyDelimiter
+		}
+	default:
+		return ErrInvalidFraming
+	}
+
+	if i.ReadMode == ahp.ReadModeEOF {
+		return ErrFramingWithEOFReadMode
+	}
+
+	if i.MaxContentLength <= 0 {
+		return ErrZeroMaxContentLengthValue
+	}
+
+	return nil
+}
+
+func (i Input) validateAddresses() (err error) {
+	if i.SRV != nil {
+		if err = i.SRV.Validate(); err != nil {
+			return err
+		}
+	}
+
+	switch i.AddressOrder {
+	case "", failover.OrderSequential, failover.OrderRandom:
+	default:
+		return ErrInvalidAddressOrder
+	}
+
+	if i.Address == "" && len(i.Addresses) == 0 && i.SRV == nil {
+		return ErrEmptyAddress
+	}
+
+	if i.Address != "" {
+		if err = validateAddress(i.Address); err != nil {
+			return err
+		}
+	}
+
+	for _, address := range i.Addresses {
+		if err = validateAddress(address); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateTransportOptions checks the transports of the candidate addresses honour the options
+// instead of dropping them: HTTP reads the whole response without framing, and neither HTTP nor
+// files are written the request payload.
+func (i Input) validateTransportOptions() (err error) {
+	for _, scheme := range i.schemes() {
+		http := scheme == "http" || scheme == "https"
+
+		switch {
+		case (http || scheme == "file") && i.RequestPayload != "":
+			return ErrRequestPayloadWithoutTCP
+		case !http:
+			continue
+		case i.Framing != ahp.FramingNone || i.Delimiter != "":
+			return ErrFramingWithHTTP
+		case i.Buffered:
+			return ErrBufferedWithHTTP
+		}
+	}
+
+	return nil
+}
+
+// validateTLS checks the TLS settings are applied to every candidate address instead of being
+// dropped by the plaintext transports.
+func (i Input) validateTLS() (err error) {
+	for _, scheme := range i.schemes() {
+		if scheme != "tls" && scheme != "https" {
+			return ErrTLSWithoutTLSAddress
+		}
+	}
+
+	return i.TLS.Validate()
+}
+
+// schemes returns the schemes of the address, the candidate addresses and the SRV records.
+func (i Input) schemes() []string {
+	addresses := make([]string, 0, len(i.Addresses)+2)
+
+	if i.Address != "" {
+		addresses = append(addresses, i.Address)
+	}
+
+	addresses = append(addresses, i.Addresses...)
+
+	if i.SRV != nil {
+		addresses = append(addresses, i.SRV.address(""))
+	}
+
+	schemes := make([]string, 0, len(addresses))
+
+	for _, address := range addresses {
+		scheme, _ := ahp.SplitAddress(address)
+		schemes = append(schemes, scheme)
+	}
+
+	return schemes
+}
+
+// ParserExpression returns the expression selecting the nodes, Expression takes precedence over
+// XPathExpression kept for compatibility.
+func (i Input) ParserExpression() string {
+	if i.Expression != "" {
+		return i.Expression
+	}
+
+	return i.XPathExpression
+}
+
+// Inline reports whether the content is carried by the input itself instead of being downloaded.
+func (i Input) Inline() bool {
+	return i.Content != ""
+}
+
+// Failover reports whether the content may be served by one of several candidate addresses.
+func (i Input) Failover() bool {
+	return len(i.Addresses) != 0 || i.SRV != nil
+}
+
+// ReadLimit returns the number of bytes passed to the downloader: the exact content length in
+// the fixed read mode and the upper bound of the content size in the EOF read mode or when the
+// content is framed by the server.
+func (i Input) ReadLimit() int64 {
+	if i.ReadMode == ahp.ReadModeEOF || i.Framing != ahp.FramingNone {
+		return i.MaxContentLength
 	}
 
-	return ErrInvalidAddress
+	return i.ContentLength
 }
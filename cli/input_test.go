@@ -1,10 +1,15 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"testing"
 	"time"
 
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/morozovcookie/afihtmlparser/failover"
+	"github.com/morozovcookie/afihtmlparser/xpath"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -143,6 +148,63 @@ func TestDuration_Duration(t *testing.T) {
 	}
 }
 
+func TestInput_ReadLimit(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		input *Input
+
+		expected int64
+	}{
+		{
+			name:    "fixed read mode",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:    10,
+				MaxContentLength: 1024,
+			},
+
+			expected: 10,
+		},
+		{
+			name:    "eof read mode",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:    10,
+				MaxContentLength: 1024,
+				ReadMode:         ahp.ReadModeEOF,
+			},
+
+			expected: 1024,
+		},
+		{
+			name:    "framing",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:    10,
+				MaxContentLength: 1024,
+				Framing:          ahp.FramingDelimiter,
+			},
+
+			expected: 1024,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			assert.Equal(t, test.expected, test.input.ReadLimit())
+		})
+	}
+}
+
 func TestInput_Validate(t *testing.T) {
 	tt := []struct {
 		name    string
@@ -169,53 +231,1091 @@ func TestInput_Validate(t *testing.T) {
 
 			input: &Input{
 				ContentLength:   10,
-				Address:         "mydomain.zone",
+				Address:         "mydomain.zone:8080",
 				XPathExpression: "//ul/li",
 			},
 		},
 		{
-			name:    "zero content length",
+			name:    "pass with ipv6 address",
 			enabled: true,
 
-			input: &Input{},
+			input: &Input{
+				ContentLength:   10,
+				Address:         "[fe80::1%eth0]:9000",
+				XPathExpression: "//ul/li",
+			},
+		},
+		{
+			name:    "hostname without port",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "mydomain.zone",
+			},
 
 			wantErr:  true,
-			expected: ErrZeroContentLengthValue,
+			expected: ErrMissingPort,
 		},
 		{
-			name:    "empty address",
+			name:    "pass with http url address",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "https://mydomain.zone:8443/page?id=42",
+				XPathExpression: "//ul/li",
+			},
+		},
+		{
+			name:    "pass with tcp scheme address",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "tcp://127.0.0.1:8080",
+				XPathExpression: "//ul/li",
+			},
+		},
+		{
+			name:    "pass with eof read mode",
+			enabled: true,
+
+			input: &Input{
+				MaxContentLength: 1024,
+				ReadMode:         ahp.ReadModeEOF,
+				Address:          "127.0.0.1:8080",
+				XPathExpression:  "//ul/li",
+			},
+		},
+		{
+			name:    "zero max content length in eof read mode",
 			enabled: true,
 
 			input: &Input{
 				ContentLength: 10,
+				ReadMode:      ahp.ReadModeEOF,
 			},
 
 			wantErr:  true,
-			expected: ErrEmptyAddress,
+			expected: ErrZeroMaxContentLengthValue,
 		},
 		{
-			name:    "invalid host:port address",
+			name:    "invalid read mode",
 			enabled: true,
 
 			input: &Input{
 				ContentLength: 10,
-				Address:       "256.789.320.752:8135135368",
+				ReadMode:      "line",
 			},
 
 			wantErr:  true,
-			expected: ErrInvalidAddress,
+			expected: ErrInvalidReadMode,
 		},
 		{
-			name:    "invalid hostname:port address",
+			name:    "pass with length prefix framing",
+			enabled: true,
+
+			input: &Input{
+				MaxContentLength: 1024,
+				Framing:          ahp.FramingUint32,
+				Address:          "127.0.0.1:8080",
+				XPathExpression:  "//ul/li",
+			},
+		},
+		{
+			name:    "invalid framing",
+			enabled: true,
+
+			input: &Input{
+				MaxContentLength: 1024,
+				Framing:          "uint8",
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidFraming,
+		},
+		{
+			name:    "empty delimiter",
+			enabled: true,
+
+			input: &Input{
+				MaxContentLength: 1024,
+				Framing:          ahp.FramingDelimiter,
+			},
+
+			wantErr:  true,
+			expected: ErrEmptyDelimiter,
+		},
+		{
+			name:    "framing with eof read mode",
+			enabled: true,
+
+			input: &Input{
+				MaxContentLength: 1024,
+				ReadMode:         ahp.ReadModeEOF,
+				Framing:          ahp.FramingVarint,
+			},
+
+			wantErr:  true,
+			expected: ErrFramingWithEOFReadMode,
+		},
+		{
+			name:    "zero max content length with framing",
 			enabled: true,
 
 			input: &Input{
 				ContentLength: 10,
-				Address:       "gsfdsfdfd%@#fdfaf",
+				Framing:       ahp.FramingUint16,
 			},
 
 			wantErr:  true,
-			expected: ErrInvalidAddress,
+			expected: ErrZeroMaxContentLengthValue,
+		},
+		{
+			name:    "pass with tls scheme address",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "tls://mydomain.zone:8443",
+				XPathExpression: "//ul/li",
+				TLS: &TLS{
+					MinVersion: "1.2",
+				},
+			},
+		},
+		{
+			name:    "pass with tls scheme candidates",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Addresses:       []string{"tls://replica-1.mydomain.zone:8443", "https://mydomain.zone"},
+				SRV:             &SRV{Name: "mydomain.zone", Scheme: "tls"},
+				XPathExpression: "//ul/li",
+				TLS:             &TLS{},
+			},
+		},
+		{
+			name:    "tls with bare address",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "mydomain.zone:8443",
+				XPathExpression: "//ul/li",
+				TLS:             &TLS{},
+			},
+
+			wantErr:  true,
+			expected: ErrTLSWithoutTLSAddress,
+		},
+		{
+			name:    "tls with tcp scheme address",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "tcp://mydomain.zone:8443",
+				XPathExpression: "//ul/li",
+				TLS:             &TLS{},
+			},
+
+			wantErr:  true,
+			expected: ErrTLSWithoutTLSAddress,
+		},
+		{
+			name:    "tls with plaintext candidate",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Addresses:       []string{"tls://replica-1.mydomain.zone:8443", "http://mydomain.zone"},
+				XPathExpression: "//ul/li",
+				TLS:             &TLS{},
+			},
+
+			wantErr:  true,
+			expected: ErrTLSWithoutTLSAddress,
+		},
+		{
+			name:    "tls with srv records without scheme",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				SRV:             &SRV{Name: "mydomain.zone"},
+				XPathExpression: "//ul/li",
+				TLS:             &TLS{},
+			},
+
+			wantErr:  true,
+			expected: ErrTLSWithoutTLSAddress,
+		},
+		{
+			name:    "request payload with http address",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:    10,
+				MaxContentLength: 100,
+				Address:          "http://mydomain.zone/page",
+				XPathExpression:  "//ul/li",
+				RequestPayload:   "GET",
+			},
+
+			wantErr:  true,
+			expected: ErrRequestPayloadWithoutTCP,
+		},
+		{
+			name:    "request payload with file candidate",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:    10,
+				MaxContentLength: 100,
+				Addresses:        []string{"tcp://mydomain.zone:8080", "file:///page.html"},
+				XPathExpression:  "//ul/li",
+				RequestPayload:   "GET",
+			},
+
+			wantErr:  true,
+			expected: ErrRequestPayloadWithoutTCP,
+		},
+		{
+			name:    "framing with https address",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:    10,
+				MaxContentLength: 100,
+				Address:          "https://mydomain.zone/page",
+				XPathExpression:  "//ul/li",
+				Framing:          ahp.FramingUint32,
+			},
+
+			wantErr:  true,
+			expected: ErrFramingWithHTTP,
+		},
+		{
+			name:    "buffered with http srv records",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:    10,
+				MaxContentLength: 100,
+				SRV:              &SRV{Name: "mydomain.zone", Scheme: "http"},
+				XPathExpression:  "//ul/li",
+				Buffered:         true,
+			},
+
+			wantErr:  true,
+			expected: ErrBufferedWithHTTP,
+		},
+		{
+			name:    "pass with framing of file",
+			enabled: true,
+
+			input: &Input{
+				MaxContentLength: 100,
+				Address:          "file:///page.html",
+				XPathExpression:  "//ul/li",
+				Framing:          ahp.FramingVarint,
+				Buffered:         true,
+			},
+		},
+		{
+			name:    "invalid tls options",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "tls://mydomain.zone:8443",
+				XPathExpression: "//ul/li",
+				TLS: &TLS{
+					KeyFile: "client.key",
+				},
+			},
+
+			wantErr:  true,
+			expected: ErrTLSCertificateWithoutKey,
+		},
+		{
+			name:    "pass with base64 request payload",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:        10,
+				Address:              "127.0.0.1:8080",
+				XPathExpression:      "//ul/li",
+				RequestPayload:       "R0VUIHBhZ2UtNDIK",
+				RequestPayloadFormat: PayloadFormatBase64,
+			},
+		},
+		{
+			name:    "invalid request payload",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:        10,
+				Address:              "127.0.0.1:8080",
+				XPathExpression:      "//ul/li",
+				RequestPayload:       "GET page-42",
+				RequestPayloadFormat: PayloadFormatBase64,
+			},
+
+			wantErr:  true,
+			expected: errors.New("input validation error: invalid request payload: illegal base64 data at input byte 3"),
+		},
+		{
+			name:    "pass with candidate addresses",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Addresses:       []string{"127.0.0.1:8080", "127.0.0.1:8081"},
+				AddressOrder:    failover.OrderRandom,
+				XPathExpression: "//ul/li",
+			},
+		},
+		{
+			name:    "pass with srv lookup",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				SRV:             &SRV{Service: "html", Name: "mydomain.zone"},
+				XPathExpression: "//ul/li",
+			},
+		},
+		{
+			name:    "invalid candidate address",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Addresses:     []string{"127.0.0.1:8080", "gsfdsfdfd@#fdfaf:8080"},
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidHost,
+		},
+		{
+			name:    "invalid address order",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Addresses:     []string{"127.0.0.1:8080"},
+				AddressOrder:  "round-robin",
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidAddressOrder,
+		},
+		{
+			name:    "empty srv name",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				SRV:           &SRV{Service: "html"},
+			},
+
+			wantErr:  true,
+			expected: ErrEmptySRVName,
+		},
+		{
+			name:    "zero content length",
+			enabled: true,
+
+			input: &Input{},
+
+			wantErr:  true,
+			expected: ErrZeroContentLengthValue,
+		},
+		{
+			name:    "empty address",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+			},
+
+			wantErr:  true,
+			expected: ErrEmptyAddress,
+		},
+		{
+			name:    "invalid host:port address",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "256.789.320.752:8080",
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidHost,
+		},
+		{
+			name:    "invalid port",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8135135368",
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidPort,
+		},
+		{
+			name:    "invalid hostname:port address",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "gsfdsfdfd@#fdfaf:8080",
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidHost,
+		},
+		{
+			name:    "invalid http url address",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "http:///page",
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidURL,
+		},
+		{
+			name:    "empty custom scheme address",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "custom://",
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidAddress,
+		},
+		{
+			name:    "pass with inline content",
+			enabled: true,
+
+			input: &Input{
+				Content:         `<li>blabla</li>`,
+				XPathExpression: "//li",
+			},
+		},
+		{
+			name:    "inline content with address",
+			enabled: true,
+
+			input: &Input{
+				Content:         `<li>blabla</li>`,
+				Address:         "127.0.0.1:8080",
+				XPathExpression: "//li",
+			},
+
+			wantErr:  true,
+			expected: ErrContentWithAddress,
+		},
+		{
+			name:    "inline content with tls",
+			enabled: true,
+
+			input: &Input{
+				Content:         `<li>blabla</li>`,
+				XPathExpression: "//li",
+				TLS:             &TLS{},
+			},
+
+			wantErr:  true,
+			expected: ErrContentWithConnection,
+		},
+		{
+			name:    "inline content with read mode",
+			enabled: true,
+
+			input: &Input{
+				Content:         `<li>blabla</li>`,
+				XPathExpression: "//li",
+				ReadMode:        ahp.ReadModeEOF,
+			},
+
+			wantErr:  true,
+			expected: ErrContentWithConnection,
+		},
+		{
+			name:    "inline content with framing",
+			enabled: true,
+
+			input: &Input{
+				Content:         `<li>blabla</li>`,
+				XPathExpression: "//li",
+				Framing:         ahp.FramingUint16,
+			},
+
+			wantErr:  true,
+			expected: ErrContentWithConnection,
+		},
+		{
+			name:    "inline content with delimiter",
+			enabled: true,
+
+			input: &Input{
+				Content:         `<li>blabla</li>`,
+				XPathExpression: "//li",
+				Delimiter:       "\r\n",
+			},
+
+			wantErr:  true,
+			expected: ErrContentWithConnection,
+		},
+		{
+			name:    "inline content with content length",
+			enabled: true,
+
+			input: &Input{
+				Content:         `<li>blabla</li>`,
+				XPathExpression: "//li",
+				ContentLength:   10,
+			},
+
+			wantErr:  true,
+			expected: ErrContentWithConnection,
+		},
+		{
+			name:    "inline content with max content length",
+			enabled: true,
+
+			input: &Input{
+				Content:          `<li>blabla</li>`,
+				XPathExpression:  "//li",
+				MaxContentLength: 100,
+			},
+
+			wantErr:  true,
+			expected: ErrContentWithConnection,
+		},
+		{
+			name:    "inline content with buffered",
+			enabled: true,
+
+			input: &Input{
+				Content:         `<li>blabla</li>`,
+				XPathExpression: "//li",
+				Buffered:        true,
+			},
+
+			wantErr:  true,
+			expected: ErrContentWithConnection,
+		},
+		{
+			name:    "inline content with address order",
+			enabled: true,
+
+			input: &Input{
+				Content:         `<li>blabla</li>`,
+				XPathExpression: "//li",
+				AddressOrder:    failover.OrderRandom,
+			},
+
+			wantErr:  true,
+			expected: ErrContentWithConnection,
+		},
+		{
+			name:    "inline content with write timeout",
+			enabled: true,
+
+			input: &Input{
+				Content:         `<li>blabla</li>`,
+				XPathExpression: "//li",
+				WriteTimeout:    Duration(time.Second),
+			},
+
+			wantErr:  true,
+			expected: ErrContentWithConnection,
+		},
+		{
+			name:    "inline content with retry",
+			enabled: true,
+
+			input: &Input{
+				Content:         `<li>blabla</li>`,
+				XPathExpression: "//li",
+				Retry:           &Retry{Attempts: 3},
+			},
+
+			wantErr:  true,
+			expected: ErrContentWithConnection,
+		},
+		{
+			name:    "inline content with request payload",
+			enabled: true,
+
+			input: &Input{
+				Content:         `<li>blabla</li>`,
+				XPathExpression: "//li",
+				RequestPayload:  "GET",
+			},
+
+			wantErr:  true,
+			expected: ErrContentWithConnection,
+		},
+		{
+			name:    "invalid base64 inline content",
+			enabled: true,
+
+			input: &Input{
+				Content:         `<li>blabla</li>`,
+				ContentFormat:   PayloadFormatBase64,
+				XPathExpression: "//li",
+			},
+
+			wantErr:  true,
+			expected: fmt.Errorf("%w: illegal base64 data at input byte 0", ErrInvalidContent),
+		},
+		{
+			name:    "invalid content encoding",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:       10,
+				Address:             "127.0.0.1:8080",
+				XPathExpression:     "//li",
+				ContentEncoding:     "br",
+				MaxDecompressedSize: 100,
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidContentEncoding,
+		},
+		{
+			name:    "zero max-decompressed-size value",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "127.0.0.1:8080",
+				XPathExpression: "//li",
+				ContentEncoding: "gzip",
+			},
+
+			wantErr:  true,
+			expected: ErrZeroMaxDecompressedSize,
+		},
+		{
+			name:    "pass with css selector",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				SelectorType:  ahp.SelectorTypeCSS,
+				Expression:    "ul > li",
+			},
+		},
+		{
+			name:    "empty css selector",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				SelectorType:  ahp.SelectorTypeCSS,
+			},
+
+			wantErr:  true,
+			expected: ErrEmptyExpression,
+		},
+		{
+			name:    "pass with jsonpath",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				SelectorType:  ahp.SelectorTypeJSONPath,
+				Expression:    "$.items[*]",
+			},
+		},
+		{
+			name:    "pass with fields",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				Fields: map[string]string{
+					"title": "//title",
+					"price": "//span[@class='price']",
+				},
+			},
+		},
+		{
+			name:    "empty field name",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				Fields: map[string]string{
+					"": "//title",
+				},
+			},
+
+			wantErr:  true,
+			expected: ErrEmptyFieldName,
+		},
+		{
+			name:    "empty field expression",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				Fields: map[string]string{
+					"title": "",
+				},
+			},
+
+			wantErr:  true,
+			expected: ErrEmptyFieldExpression,
+		},
+		{
+			name:    "pass with schema",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				Schema: &xpath.Schema{
+					Root: "//div[@class='product']",
+					Fields: map[string]xpath.Field{
+						"title": {Expression: ".//h2"},
+					},
+				},
+			},
+		},
+		{
+			name:    "schema with css selector",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				SelectorType:  ahp.SelectorTypeCSS,
+				Schema: &xpath.Schema{
+					Root: "//div[@class='product']",
+				},
+			},
+
+			wantErr:  true,
+			expected: ErrSchemaWithoutXPath,
+		},
+		{
+			name:    "schema with expression",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				Expression:    "//div",
+				Schema: &xpath.Schema{
+					Root: "//div[@class='product']",
+				},
+			},
+
+			wantErr:  true,
+			expected: ErrSchemaWithExpression,
+		},
+		{
+			name:    "schema with render mode",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				RenderMode:    ahp.RenderModeText,
+				Schema: &xpath.Schema{
+					Root: "//div[@class='product']",
+				},
+			},
+
+			wantErr:  true,
+			expected: ErrSchemaWithRenderMode,
+		},
+		{
+			name:    "schema with escaped html",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				EscapeHTML:    true,
+				Schema: &xpath.Schema{
+					Root: "//div[@class='product']",
+				},
+			},
+
+			wantErr:  true,
+			expected: ErrSchemaWithRenderMode,
+		},
+		{
+			name:    "empty schema root",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				Schema:        &xpath.Schema{},
+			},
+
+			wantErr:  true,
+			expected: ErrEmptySchemaRoot,
+		},
+		{
+			name:    "empty nested schema field expression",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				Schema: &xpath.Schema{
+					Root: "//div[@class='product']",
+					Fields: map[string]xpath.Field{
+						"seller": {
+							Expression: ".//div",
+							Fields: map[string]xpath.Field{
+								"name": {},
+							},
+						},
+					},
+				},
+			},
+
+			wantErr:  true,
+			expected: ErrEmptyFieldExpression,
+		},
+		{
+			name:    "pass with render mode",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "127.0.0.1:8080",
+				XPathExpression: "//a/@href",
+				RenderMode:      ahp.RenderModeAttribute,
+			},
+		},
+		{
+			name:    "invalid render mode",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "127.0.0.1:8080",
+				XPathExpression: "//a",
+				RenderMode:      "markdown",
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidRenderMode,
+		},
+		{
+			name:    "attribute render mode with css selector",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				SelectorType:  ahp.SelectorTypeCSS,
+				Expression:    "a",
+				RenderMode:    ahp.RenderModeAttribute,
+			},
+
+			wantErr:  true,
+			expected: ErrUnsupportedRenderMode,
+		},
+		{
+			name:    "render mode with jsonpath",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				SelectorType:  ahp.SelectorTypeJSONPath,
+				Expression:    "$.items[*]",
+				RenderMode:    ahp.RenderModeText,
+			},
+
+			wantErr:  true,
+			expected: ErrUnsupportedRenderMode,
+		},
+		{
+			name:    "escape html with xml",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "127.0.0.1:8080",
+				XPathExpression: "//entry",
+				DocumentType:    ahp.DocumentTypeXML,
+				EscapeHTML:      true,
+			},
+
+			wantErr:  true,
+			expected: ErrEscapeHTMLWithoutHTML,
+		},
+		{
+			name:    "pass with transforms",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "127.0.0.1:8080",
+				XPathExpression: "//li",
+				Transforms: Transforms{
+					{Type: TransformTypeTrim},
+				},
+				Fields: map[string]string{
+					"price": "//span",
+				},
+				FieldTransforms: map[string]Transforms{
+					"price": {{Type: TransformTypeToFloat}},
+				},
+			},
+		},
+		{
+			name:    "unknown transform",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "127.0.0.1:8080",
+				XPathExpression: "//li",
+				Transforms: Transforms{
+					{Type: "capitalize"},
+				},
+			},
+
+			wantErr:  true,
+			expected: ErrUnknownTransform,
+		},
+		{
+			name:    "transforms of unknown field",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "127.0.0.1:8080",
+				XPathExpression: "//li",
+				FieldTransforms: map[string]Transforms{
+					"price": {{Type: TransformTypeToFloat}},
+				},
+			},
+
+			wantErr:  true,
+			expected: ErrTransformsOfUnknownField,
+		},
+		{
+			name:    "invalid selector type",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				SelectorType:  "regexp",
+				Expression:    "<li>.*</li>",
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidSelectorType,
+		},
+		{
+			name:    "pass with xml document type",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "127.0.0.1:8080",
+				XPathExpression: "//a:entry",
+				DocumentType:    ahp.DocumentTypeXML,
+				Namespaces: map[string]string{
+					"a": "http://www.w3.org/2005/Atom",
+				},
+			},
+		},
+		{
+			name:    "invalid document type",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "127.0.0.1:8080",
+				XPathExpression: "//li",
+				DocumentType:    "json",
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidDocumentType,
+		},
+		{
+			name:    "xml document type with css selector",
+			enabled: true,
+
+			input: &Input{
+				ContentLength: 10,
+				Address:       "127.0.0.1:8080",
+				SelectorType:  ahp.SelectorTypeCSS,
+				Expression:    "entry",
+				DocumentType:  ahp.DocumentTypeXML,
+			},
+
+			wantErr:  true,
+			expected: ErrXMLWithoutXPath,
+		},
+		{
+			name:    "namespaces with html document type",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "127.0.0.1:8080",
+				XPathExpression: "//a:entry",
+				Namespaces: map[string]string{
+					"a": "http://www.w3.org/2005/Atom",
+				},
+			},
+
+			wantErr:  true,
+			expected: ErrNamespacesWithHTML,
+		},
+		{
+			name:    "empty namespace prefix",
+			enabled: true,
+
+			input: &Input{
+				ContentLength:   10,
+				Address:         "127.0.0.1:8080",
+				XPathExpression: "//entry",
+				DocumentType:    ahp.DocumentTypeXML,
+				Namespaces: map[string]string{
+					"": "http://www.w3.org/2005/Atom",
+				},
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidNamespace,
 		},
 		{
 			name:    "empty xpath expression",
@@ -4,4 +4,23 @@ type Output struct {
 	Success      bool     `json:"success"`
 	ErrorMessage string   `json:"error-message,omitempty"`
 	Nodes        []string `json:"nodes,omitempty"`
+	Attempts     int      `json:"attempts,omitempty"`
+	ServedBy     string   `json:"served-by,omitempty"`
+	Charset      string   `json:"charset,omitempty"`
+
+	// Value is the number, string or boolean the expression evaluates to instead of nodes.
+	Value interface{} `json:"value,omitempty"`
+	// Values are the nodes passed through the transforms of the expression.
+	Values []interface{} `json:"values,omitempty"`
+
+	Fields map[string]Field `json:"fields,omitempty"`
+	// Records are the records extracted by the schema, omitted like the nodes when there are none.
+	Records []map[string]interface{} `json:"records,omitempty"`
+}
+
+// Field is the result of the named expression of the input fields.
+type Field struct {
+	Nodes  []string      `json:"nodes,omitempty"`
+	Value  interface{}   `json:"value,omitempty"`
+	Values []interface{} `json:"values,omitempty"`
 }
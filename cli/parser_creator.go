@@ -4,4 +4,4 @@ import (
 	ahp "github.com/morozovcookie/afihtmlparser"
 )
 
-type ParserCreator func(expression string) ahp.Parser
+type ParserCreator func(cfg ahp.ParserConfig) ahp.Parser
@@ -1,23 +1,81 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net"
+	"sort"
+	"strings"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/morozovcookie/afihtmlparser/decompress"
+	"github.com/morozovcookie/afihtmlparser/failover"
+	"github.com/morozovcookie/afihtmlparser/retry"
+	"github.com/morozovcookie/afihtmlparser/xpath"
+)
+
+var (
+	ErrFieldsNotSupported = errors.New("parser does not support fields")
+	ErrSchemaNotSupported = errors.New("parser does not support schema")
 )
 
 type ParseService struct {
-	dc DownloaderCreator
-	pc ParserCreator
+	dc       DownloaderCreator
+	pc       ParserCreator
+	resolver failover.Resolver
+	policy   ahp.NetworkPolicy
+}
+
+type Option func(svc *ParseService)
+
+// WithResolver sets the resolver of the SRV records, net.DefaultResolver is used by default.
+func WithResolver(resolver failover.Resolver) Option {
+	return func(svc *ParseService) {
+		svc.resolver = resolver
+	}
+}
+
+// charsetParser is a parser transcoding the documents, which reports the charset of the last one.
+type charsetParser interface {
+	Charset() string
+}
+
+// recordExtractor is a parser extracting the records described by a schema.
+type recordExtractor interface {
+	Extract(ctx context.Context, r io.Reader, schema xpath.Schema) (records []map[string]interface{}, err error)
 }
 
-func NewParseService(dc DownloaderCreator, pc ParserCreator) *ParseService {
-	return &ParseService{
-		dc: dc,
-		pc: pc,
+// WithNetworkPolicy restricts the hosts the downloaders may connect to.
+func WithNetworkPolicy(policy ahp.NetworkPolicy) Option {
+	return func(svc *ParseService) {
+		svc.policy = policy
+	}
+}
+
+func NewParseService(dc DownloaderCreator, pc ParserCreator, opts ...Option) *ParseService {
+	svc := &ParseService{
+		dc:       dc,
+		pc:       pc,
+		resolver: net.DefaultResolver,
+	}
+
+	for _, opt := range opts {
+		opt(svc)
 	}
+
+	return svc
 }
 
 func (svc *ParseService) Parse(w io.Writer, r io.Reader) (err error) {
+	return svc.ParseContext(context.Background(), w, r)
+}
+
+// ParseContext is Parse which cancels downloading and parsing once the context is done or the
+// input deadline is exceeded.
+func (svc *ParseService) ParseContext(ctx context.Context, w io.Writer, r io.Reader) (err error) {
 	var (
 		in = &Input{
 			DialTimeout: DefaultDialTimeout,
@@ -31,7 +89,11 @@ func (svc *ParseService) Parse(w io.Writer, r io.Reader) (err error) {
 			return
 		}
 
-		*err = json.NewEncoder(w).Encode(&Output{ErrorMessage: (*err).Error()})
+		*err = json.NewEncoder(w).Encode(&Output{
+			ErrorMessage: (*err).Error(),
+			Attempts:     out.Attempts,
+			ServedBy:     out.ServedBy,
+		})
 	}(w, &err)
 
 	if err = json.NewDecoder(r).Decode(in); err != nil {
@@ -42,20 +104,115 @@ func (svc *ParseService) Parse(w io.Writer, r io.Reader) (err error) {
 		return err
 	}
 
-	var (
-		downloader = svc.dc(in.Address, in.DialTimeout.Duration())
+	// The write timeout is defaulted after the validation, which rejects it with inline content.
+	if in.WriteTimeout == 0 {
+		in.WriteTimeout = DefaultWriteTimeout
+	}
 
-		callback = func(r io.Reader) (err error) {
-			if out.Nodes, err = svc.pc(in.XPathExpression).Parse(r); err != nil {
-				return err
-			}
+	if in.Deadline > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, in.Deadline.Duration())
+		defer cancel()
+	}
+
+	cfg := ahp.DownloaderConfig{
+		DialTimeout:  in.DialTimeout.Duration(),
+		ReadMode:     in.ReadMode,
+		Framing:      in.Framing,
+		Delimiter:    []byte(in.Delimiter),
+		WriteTimeout: in.WriteTimeout.Duration(),
+		Buffered:     in.Buffered,
+		Policy:       svc.policy,
+	}
 
-			return nil
+	if cfg.RequestPayload, err = in.RequestPayloadFormat.Decode(in.RequestPayload); err != nil {
+		return err
+	}
+
+	if in.TLS != nil {
+		if cfg.TLS, err = in.TLS.Config(); err != nil {
+			return err
 		}
+	}
+
+	var (
+		downloader ahp.Downloader
+		fd         *failover.Downloader
 	)
 
-	if err = downloader.Download(in.ContentLength, in.ReadTimeout.Duration(), callback); err != nil {
-		return
+	switch {
+	case in.Inline():
+		var content []byte
+
+		if content, err = in.ContentFormat.Decode(in.Content); err != nil {
+			return err
+		}
+
+		downloader = contentDownloader(content)
+	case in.Failover():
+		if fd, err = svc.failoverDownloader(ctx, in, cfg); err != nil {
+			return err
+		}
+
+		downloader = fd
+	default:
+		cfg.Address = in.Address
+
+		if downloader, err = svc.dc(cfg); err != nil {
+			return err
+		}
+	}
+
+	if in.ContentEncoding != decompress.EncodingNone {
+		downloader = decompress.NewDownloader(downloader, in.ContentEncoding, in.MaxDecompressedSize)
+	}
+
+	var rd *retry.Downloader
+
+	if in.Retry != nil {
+		rd = retry.NewDownloader(downloader, in.Retry.Attempts, in.Retry.Backoff())
+		downloader = rd
+	}
+
+	parser := svc.pc(ahp.ParserConfig{
+		SelectorType: in.SelectorType,
+		Expression:   in.ParserExpression(),
+		Charset:      in.Charset,
+		DocumentType: in.DocumentType,
+		Namespaces:   in.Namespaces,
+		RenderMode:   in.RenderMode,
+		EscapeHTML:   in.EscapeHTML,
+	})
+
+	callback := func(r io.Reader) (err error) {
+		if err = parse(ctx, parser, r, in, out); err != nil {
+			return err
+		}
+
+		if cp, ok := parser.(charsetParser); ok {
+			out.Charset = cp.Charset()
+		}
+
+		return nil
+	}
+
+	err = ahp.DownloaderWithContext(downloader).DownloadContext(ctx, in.ReadLimit(), in.ReadTimeout.Duration(), callback)
+
+	if rd != nil {
+		out.Attempts = rd.Attempts()
+	}
+
+	if fd != nil {
+		out.ServedBy = fd.ServedBy()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err = transform(in, out); err != nil {
+		return err
 	}
 
 	enc := json.NewEncoder(w)
@@ -63,3 +220,216 @@ func (svc *ParseService) Parse(w io.Writer, r io.Reader) (err error) {
 
 	return enc.Encode(out)
 }
+
+func parse(ctx context.Context, parser ahp.Parser, r io.Reader, in *Input, out *Output) (err error) {
+	if in.Schema != nil {
+		re, ok := parser.(recordExtractor)
+		if !ok {
+			return ErrSchemaNotSupported
+		}
+
+		out.Records, err = re.Extract(ctx, r, *in.Schema)
+
+		return err
+	}
+
+	if len(in.Fields) != 0 {
+		return parseFields(ctx, parser, r, in, out)
+	}
+
+	if ev, ok := parser.(ahp.Evaluator); ok {
+		var result ahp.Result
+		if result, err = ev.EvaluateContext(ctx, r); err != nil {
+			return err
+		}
+
+		out.Nodes, out.Value = result.Nodes, result.Value
+
+		return nil
+	}
+
+	out.Nodes, err = ahp.ParserWithContext(parser).ParseContext(ctx, r)
+
+	return err
+}
+
+// parseFields parses the document once and evaluates the expression, when it's set, and all the
+// named expressions of the fields against it.
+func parseFields(ctx context.Context, parser ahp.Parser, r io.Reader, in *Input, out *Output) (err error) {
+	dp, ok := parser.(ahp.DocumentParser)
+	if !ok {
+		return ErrFieldsNotSupported
+	}
+
+	doc, err := dp.ParseDocument(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	var result ahp.Result
+
+	if expression := in.ParserExpression(); expression != "" {
+		if result, err = doc.Evaluate(ctx, expression); err != nil {
+			return err
+		}
+
+		out.Nodes, out.Value = result.Nodes, result.Value
+	}
+
+	names := make([]string, 0, len(in.Fields))
+	for name := range in.Fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	out.Fields = make(map[string]Field, len(names))
+
+	for _, name := range names {
+		if result, err = doc.Evaluate(ctx, in.Fields[name]); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+
+		out.Fields[name] = Field{Nodes: result.Nodes, Value: result.Value}
+	}
+
+	return nil
+}
+
+// transform passes the results of the expression and the fields through their transforms. The
+// transformed nodes are returned in the values, while the value of a scalar expression is replaced.
+func transform(in *Input, out *Output) (err error) {
+	decode := in.SelectorType == ahp.SelectorTypeJSONPath
+
+	if out.Values, out.Value, err = applyTransforms(in.Transforms, out.Nodes, out.Value, decode); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(in.FieldTransforms))
+	for name := range in.FieldTransforms {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := out.Fields[name]
+
+		if f.Values, f.Value, err = applyTransforms(in.FieldTransforms[name], f.Nodes, f.Value, decode); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+
+		out.Fields[name] = f
+	}
+
+	return nil
+}
+
+// applyTransforms passes the value or every node through the transforms. The JSON nodes are decoded
+// for the transforms to apply to the strings and the numbers rather than their serialized form.
+func applyTransforms(tt Transforms, nodes []string, value interface{}, decode bool) (values []interface{},
+	_ interface{}, err error) {
+	if len(tt) == 0 {
+		return nil, value, nil
+	}
+
+	if value != nil {
+		value, err = tt.Apply(value)
+
+		return nil, value, err
+	}
+
+	// Nothing is selected, so the default is the value of the empty string.
+	if len(nodes) == 0 && tt.hasDefault() {
+		if value, err = tt.Apply(""); err != nil {
+			return nil, nil, err
+		}
+
+		return []interface{}{value}, nil, nil
+	}
+
+	values = make([]interface{}, 0, len(nodes))
+
+	for _, node := range nodes {
+		var v interface{} = node
+
+		if decode {
+			if v, err = jsonValue(node); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if v, err = tt.Apply(v); err != nil {
+			return nil, nil, err
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil, nil
+}
+
+// jsonValue decodes the JSON node, the integers into int64 and the other numbers into float64.
+func jsonValue(node string) (v interface{}, err error) {
+	dec := json.NewDecoder(strings.NewReader(node))
+	dec.UseNumber()
+
+	if err = dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTransform, err)
+	}
+
+	n, ok := v.(json.Number)
+	if !ok {
+		return v, nil
+	}
+
+	if v, err = n.Int64(); err == nil {
+		return v, nil
+	}
+
+	if v, err = n.Float64(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTransform, err)
+	}
+
+	return v, nil
+}
+
+func (svc *ParseService) failoverDownloader(ctx context.Context, in *Input,
+	cfg ahp.DownloaderConfig) (d *failover.Downloader, err error) {
+	addresses := make([]string, 0, len(in.Addresses)+1)
+
+	if in.Address != "" {
+		addresses = append(addresses, in.Address)
+	}
+
+	addresses = append(addresses, in.Addresses...)
+
+	if in.SRV != nil {
+		resolved, err := failover.LookupSRV(ctx, svc.resolver, in.SRV.Service, in.SRV.proto(), in.SRV.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, address := range resolved {
+			addresses = append(addresses, in.SRV.address(address))
+		}
+	}
+
+	candidates := make([]failover.Candidate, 0, len(addresses))
+
+	for _, address := range addresses {
+		cfg.Address = address
+
+		downloader, err := svc.dc(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates = append(candidates, failover.Candidate{
+			Address:    address,
+			Downloader: downloader,
+		})
+	}
+
+	return failover.NewDownloader(candidates, in.AddressOrder), nil
+}
@@ -2,23 +2,41 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"net"
 	"testing"
 	"time"
 
 	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/morozovcookie/afihtmlparser/jsonpath"
+	"github.com/morozovcookie/afihtmlparser/policy"
+	"github.com/morozovcookie/afihtmlparser/xpath"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+type blockingDownloader struct{}
+
+func (d blockingDownloader) Download(contentLength int64, timeout time.Duration, callback ahp.DownloadCallback) error {
+	return d.DownloadContext(context.Background(), contentLength, timeout, callback)
+}
+
+func (blockingDownloader) DownloadContext(ctx context.Context, _ int64, _ time.Duration, _ ahp.DownloadCallback) error {
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
 func TestParseService_Parse(t *testing.T) {
 	tt := []struct {
 		name    string
 		enabled bool
 
-		downloader func() ahp.Downloader
+		downloader    func() ahp.Downloader
+		downloaderErr error
 
 		parser       *ahp.MockParser
 		parserInput  []interface{}
@@ -182,6 +200,78 @@ func TestParseService_Parse(t *testing.T) {
 				return buf.String()
 			},
 		},
+		{
+			name:    "downloader creation error",
+			enabled: true,
+
+			downloader: func() ahp.Downloader {
+				return nil
+			},
+			downloaderErr: errors.New("unknown address scheme: ftp"),
+
+			parser:       &ahp.MockParser{},
+			parserInput:  []interface{}{},
+			parserOutput: []interface{}{},
+
+			input: bytes.NewBufferString(
+				`{"content-length":10,"address":"ftp://127.0.0.1:8080","xpath-expression":"//ul/li"}`),
+
+			expected: func(t *testing.T) string {
+				var (
+					buf = &bytes.Buffer{}
+
+					out = &Output{
+						Success:      false,
+						ErrorMessage: "unknown address scheme: ftp",
+					}
+				)
+
+				enc := json.NewEncoder(buf)
+				enc.SetEscapeHTML(false)
+
+				if err := enc.Encode(out); err != nil {
+					t.Fatal(err)
+				}
+
+				return buf.String()
+			},
+		},
+		{
+			name:    "tls configuration error",
+			enabled: true,
+
+			downloader: func() ahp.Downloader {
+				return nil
+			},
+
+			parser:       &ahp.MockParser{},
+			parserInput:  []interface{}{},
+			parserOutput: []interface{}{},
+
+			input: bytes.NewBufferString(
+				`{"content-length":10,"address":"tls://127.0.0.1:8080","xpath-expression":"//ul/li",` +
+					`"tls":{"ca-file":"/nonexistent/ca.pem"}}`),
+
+			expected: func(t *testing.T) string {
+				var (
+					buf = &bytes.Buffer{}
+
+					out = &Output{
+						Success:      false,
+						ErrorMessage: "tls configuration error: open /nonexistent/ca.pem: no such file or directory",
+					}
+				)
+
+				enc := json.NewEncoder(buf)
+				enc.SetEscapeHTML(false)
+
+				if err := enc.Encode(out); err != nil {
+					t.Fatal(err)
+				}
+
+				return buf.String()
+			},
+		},
 		{
 			name:    "download error",
 			enabled: true,
@@ -235,6 +325,96 @@ func TestParseService_Parse(t *testing.T) {
 				return buf.String()
 			},
 		},
+		{
+			name:    "download error after retries",
+			enabled: true,
+
+			downloader: func() ahp.Downloader {
+				var (
+					downloader = &ahp.MockDownloader{}
+
+					input = []interface{}{
+						int64(10),
+						time.Second,
+						mock.AnythingOfType("afihtmlparser.DownloadCallback"),
+					}
+
+					output = []interface{}{
+						io.ErrUnexpectedEOF,
+					}
+				)
+
+				downloader.
+					On("Download", input...).
+					Return(output...)
+
+				return downloader
+			},
+
+			parser:       &ahp.MockParser{},
+			parserInput:  []interface{}{},
+			parserOutput: []interface{}{},
+
+			input: bytes.NewBufferString(
+				`{"content-length":10,"address":"127.0.0.1:8080","xpath-expression":"//ul/li",` +
+					`"retry":{"attempts":2,"initial-backoff":"1ms"}}`),
+
+			expected: func(t *testing.T) string {
+				var (
+					buf = &bytes.Buffer{}
+
+					out = &Output{
+						Success:      false,
+						ErrorMessage: "unexpected EOF",
+						Attempts:     2,
+					}
+				)
+
+				enc := json.NewEncoder(buf)
+				enc.SetEscapeHTML(false)
+
+				if err := enc.Encode(out); err != nil {
+					t.Fatal(err)
+				}
+
+				return buf.String()
+			},
+		},
+		{
+			name:    "deadline exceeded",
+			enabled: true,
+
+			downloader: func() ahp.Downloader {
+				return blockingDownloader{}
+			},
+
+			parser:       &ahp.MockParser{},
+			parserInput:  []interface{}{},
+			parserOutput: []interface{}{},
+
+			input: bytes.NewBufferString(
+				`{"content-length":10,"address":"127.0.0.1:8080","xpath-expression":"//ul/li","deadline":"10ms"}`),
+
+			expected: func(t *testing.T) string {
+				var (
+					buf = &bytes.Buffer{}
+
+					out = &Output{
+						Success:      false,
+						ErrorMessage: "context deadline exceeded",
+					}
+				)
+
+				enc := json.NewEncoder(buf)
+				enc.SetEscapeHTML(false)
+
+				if err := enc.Encode(out); err != nil {
+					t.Fatal(err)
+				}
+
+				return buf.String()
+			},
+		},
 		{
 			name:    "parse error",
 			enabled: true,
@@ -288,11 +468,11 @@ func TestParseService_Parse(t *testing.T) {
 				Return(test.parserOutput...)
 
 			var (
-				downloaderCreator = func(_ string, _ time.Duration) ahp.Downloader {
-					return test.downloader()
+				downloaderCreator = func(_ ahp.DownloaderConfig) (ahp.Downloader, error) {
+					return test.downloader(), test.downloaderErr
 				}
 
-				parserCreator = func(_ string) ahp.Parser {
+				parserCreator = func(_ ahp.ParserConfig) ahp.Parser {
 					return test.parser
 				}
 
@@ -309,3 +489,384 @@ func TestParseService_Parse(t *testing.T) {
 		})
 	}
 }
+
+type stubResolver struct {
+	records []*net.SRV
+}
+
+func (r stubResolver) LookupSRV(_ context.Context, _, _, _ string) (string, []*net.SRV, error) {
+	return "", r.records, nil
+}
+
+func TestParseService_ParseWithFailover(t *testing.T) {
+	var (
+		resolver = stubResolver{
+			records: []*net.SRV{
+				{Target: "replica-1.mydomain.zone.", Port: 8080, Priority: 10},
+				{Target: "replica-2.mydomain.zone.", Port: 8080, Priority: 20},
+			},
+		}
+
+		downloaderCreator = func(cfg ahp.DownloaderConfig) (ahp.Downloader, error) {
+			if cfg.Address != "tls://replica-2.mydomain.zone:8080" {
+				downloader := &ahp.MockDownloader{}
+
+				downloader.
+					On("Download", int64(10), time.Second, mock.AnythingOfType("afihtmlparser.DownloadCallback")).
+					Return(errors.New("connection refused"))
+
+				return downloader, nil
+			}
+
+			return ahp.NewMockDownloaderWithParser(bytes.NewBufferString(`<li>blabla</li>`)), nil
+		}
+
+		parserCreator = func(_ ahp.ParserConfig) ahp.Parser {
+			return xpath.NewParser(`//li`)
+		}
+
+		input = bytes.NewBufferString(`{"content-length":10,"addresses":["127.0.0.1:8080"],` +
+			`"srv":{"service":"html","name":"mydomain.zone","scheme":"tls"},"xpath-expression":"//li"}`)
+
+		actual = &bytes.Buffer{}
+	)
+
+	err := NewParseService(downloaderCreator, parserCreator, WithResolver(resolver)).Parse(actual, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.JSONEq(t,
+		`{"success":true,"nodes":["<li>blabla</li>"],"served-by":"tls://replica-2.mydomain.zone:8080","charset":"utf-8"}`,
+		actual.String())
+}
+
+func TestParseService_ParseWithNetworkPolicy(t *testing.T) {
+	p, err := policy.New(policy.Config{DenyPrivate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		downloaderCreator = func(cfg ahp.DownloaderConfig) (ahp.Downloader, error) {
+			downloader := &ahp.MockDownloader{}
+
+			downloader.
+				On("Download", int64(10), time.Second, mock.AnythingOfType("afihtmlparser.DownloadCallback")).
+				Return(cfg.Policy.CheckAddr("tcp", cfg.Address))
+
+			return downloader, nil
+		}
+
+		parserCreator = func(_ ahp.ParserConfig) ahp.Parser {
+			return xpath.NewParser(`//li`)
+		}
+
+		input  = bytes.NewBufferString(`{"content-length":10,"address":"127.0.0.1:8080","xpath-expression":"//li"}`)
+		actual = &bytes.Buffer{}
+	)
+
+	err = NewParseService(downloaderCreator, parserCreator, WithNetworkPolicy(p)).Parse(actual, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.JSONEq(t,
+		`{"success":false,"error-message":"network policy violation: address 127.0.0.1 is denied"}`,
+		actual.String())
+}
+
+func TestParseService_ParseInlineContent(t *testing.T) {
+	var (
+		downloaderCreator = func(_ ahp.DownloaderConfig) (ahp.Downloader, error) {
+			t.Fatal("unexpected downloader")
+
+			return nil, nil
+		}
+
+		parserCreator = func(cfg ahp.ParserConfig) ahp.Parser {
+			return xpath.NewParser(cfg.Expression, xpath.WithCharset(cfg.Charset))
+		}
+
+		input = bytes.NewBufferString(`{"content":"PHVsPjxsaT5ibGFibGE8L2xpPjwvdWw+","content-format":"base64",` +
+			`"xpath-expression":"//ul/li"}`)
+
+		actual = &bytes.Buffer{}
+	)
+
+	if err := NewParseService(downloaderCreator, parserCreator).Parse(actual, input); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.JSONEq(t, `{"success":true,"nodes":["<li>blabla</li>"],"charset":"utf-8"}`, actual.String())
+}
+
+func TestParseService_ParseScalarValue(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		expression string
+
+		expected string
+	}{
+		{
+			name:    "number",
+			enabled: true,
+
+			expression: `count(//ul/li)`,
+
+			expected: `{"success":true,"value":1,"charset":"utf-8"}`,
+		},
+		{
+			name:    "false",
+			enabled: true,
+
+			expression: `boolean(//table)`,
+
+			expected: `{"success":true,"value":false,"charset":"utf-8"}`,
+		},
+		{
+			name:    "string",
+			enabled: true,
+
+			expression: `string(//ul/li)`,
+
+			expected: `{"success":true,"value":"blabla","charset":"utf-8"}`,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			var (
+				downloaderCreator = func(_ ahp.DownloaderConfig) (ahp.Downloader, error) {
+					return nil, nil
+				}
+
+				parserCreator = func(cfg ahp.ParserConfig) ahp.Parser {
+					return xpath.NewParser(cfg.Expression, xpath.WithCharset(cfg.Charset))
+				}
+
+				input = bytes.NewBufferString(`{"content":"<ul><li>blabla</li></ul>","expression":"` +
+					test.expression + `"}`)
+
+				actual = &bytes.Buffer{}
+			)
+
+			if err := NewParseService(downloaderCreator, parserCreator).Parse(actual, input); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.JSONEq(t, test.expected, actual.String())
+		})
+	}
+}
+
+// countingParser counts the documents parsed by the parser.
+type countingParser struct {
+	*xpath.Parser
+
+	parsed int
+}
+
+func (p *countingParser) ParseDocument(ctx context.Context, r io.Reader) (ahp.Document, error) {
+	p.parsed++
+
+	return p.Parser.ParseDocument(ctx, r)
+}
+
+func TestParseService_ParseFields(t *testing.T) {
+	var (
+		downloaded int
+
+		downloaderCreator = func(_ ahp.DownloaderConfig) (ahp.Downloader, error) {
+			downloaded++
+
+			return ahp.NewMockDownloaderWithParser(bytes.NewBufferString(`<html><head><title>Item</title></head>` +
+				`<body><span class="price">42</span><a href="/1">1</a><a href="/2">2</a></body></html>`)), nil
+		}
+
+		parser = &countingParser{}
+
+		parserCreator = func(cfg ahp.ParserConfig) ahp.Parser {
+			parser.Parser = xpath.NewParser(cfg.Expression, xpath.WithCharset(cfg.Charset))
+
+			return parser
+		}
+
+		input = bytes.NewBufferString(`{"content-length":10,"address":"127.0.0.1:8080","fields":{` +
+			`"title":"string(//title)","price":"number(//span[@class='price'])","links":"//a"}}`)
+
+		actual = &bytes.Buffer{}
+	)
+
+	if err := NewParseService(downloaderCreator, parserCreator).Parse(actual, input); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.JSONEq(t, `{"success":true,"charset":"utf-8","fields":{"title":{"value":"Item"},"price":{"value":42},`+
+		`"links":{"nodes":["<a href=\"/1\">1</a>","<a href=\"/2\">2</a>"]}}}`, actual.String())
+	assert.Equal(t, 1, downloaded)
+	assert.Equal(t, 1, parser.parsed)
+}
+
+func TestParseService_ParseSchema(t *testing.T) {
+	var (
+		downloaderCreator = func(_ ahp.DownloaderConfig) (ahp.Downloader, error) {
+			return nil, nil
+		}
+
+		parserCreator = func(cfg ahp.ParserConfig) ahp.Parser {
+			return xpath.NewParser(cfg.Expression, xpath.WithCharset(cfg.Charset))
+		}
+
+		input = bytes.NewBufferString(`{"content":"<div class='product'><h2>Phone</h2><a href='/phone'>Details</a>` +
+			`</div><div class='product'><h2>Case</h2></div>","schema":{"root":"//div[@class='product']","fields":{` +
+			`"title":{"expression":".//h2"},"link":{"expression":".//a/@href"}}}}`)
+
+		actual = &bytes.Buffer{}
+	)
+
+	if err := NewParseService(downloaderCreator, parserCreator).Parse(actual, input); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.JSONEq(t, `{"success":true,"charset":"utf-8","records":[{"title":"Phone","link":"/phone"},`+
+		`{"title":"Case","link":null}]}`, actual.String())
+}
+
+func TestParseService_ParseTransforms(t *testing.T) {
+	var (
+		downloaderCreator = func(_ ahp.DownloaderConfig) (ahp.Downloader, error) {
+			return nil, nil
+		}
+
+		parserCreator = func(cfg ahp.ParserConfig) ahp.Parser {
+			return xpath.NewParser(cfg.Expression, xpath.WithCharset(cfg.Charset),
+				xpath.WithRenderMode(cfg.RenderMode))
+		}
+
+		input = bytes.NewBufferString(`{"content":"<ul><li> Price: 10 </li><li>Price: 25</li></ul>",` +
+			`"expression":"//li","render-mode":"text","transforms":[` +
+			`{"type":"regex-extract","pattern":"\\d+"},{"type":"to-int"}],` +
+			`"fields":{"count":"count(//li)","sold-out":"//li[@class='sold-out']"},` +
+			`"field-transforms":{"count":[{"type":"to-int"}],"sold-out":[{"type":"default-if-empty","value":"no"}]}}`)
+
+		actual = &bytes.Buffer{}
+	)
+
+	if err := NewParseService(downloaderCreator, parserCreator).Parse(actual, input); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.JSONEq(t, `{"success":true,"charset":"utf-8","nodes":["Price: 10","Price: 25"],"values":[10,25],`+
+		`"fields":{"count":{"value":2},"sold-out":{"values":["no"]}}}`, actual.String())
+}
+
+func TestParseService_ParseJSONTransforms(t *testing.T) {
+	var (
+		downloaderCreator = func(_ ahp.DownloaderConfig) (ahp.Downloader, error) {
+			return nil, nil
+		}
+
+		parserCreator = func(cfg ahp.ParserConfig) ahp.Parser {
+			return jsonpath.NewParser(cfg.Expression, jsonpath.WithCharset(cfg.Charset))
+		}
+
+		input = bytes.NewBufferString(`{"content":"{\"price\":\" 42 \",\"count\":7}",` +
+			`"selector-type":"jsonpath","expression":"$.price",` +
+			`"transforms":[{"type":"trim"},{"type":"to-int"}],` +
+			`"fields":{"count":"$.count"},"field-transforms":{"count":[{"type":"to-float"}]}}`)
+
+		actual = &bytes.Buffer{}
+	)
+
+	if err := NewParseService(downloaderCreator, parserCreator).Parse(actual, input); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.JSONEq(t, `{"success":true,"charset":"utf-8","nodes":["\" 42 \""],"values":[42],`+
+		`"fields":{"count":{"nodes":["7"],"values":[7]}}}`, actual.String())
+}
+
+func TestParseService_ParseTransformError(t *testing.T) {
+	var (
+		downloaderCreator = func(_ ahp.DownloaderConfig) (ahp.Downloader, error) {
+			return nil, nil
+		}
+
+		parserCreator = func(cfg ahp.ParserConfig) ahp.Parser {
+			return xpath.NewParser(cfg.Expression, xpath.WithCharset(cfg.Charset))
+		}
+
+		input = bytes.NewBufferString(`{"content":"<ul><li>ten</li></ul>","expression":"string(//li)",` +
+			`"transforms":[{"type":"to-int"}]}`)
+
+		actual = &bytes.Buffer{}
+	)
+
+	if err := NewParseService(downloaderCreator, parserCreator).Parse(actual, input); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.JSONEq(t, `{"success":false,"error-message":"transform error: to-int: strconv.ParseInt: `+
+		`parsing \"ten\": invalid syntax"}`, actual.String())
+}
+
+func TestParseService_ParseCompressedContent(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		maxSize string
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			maxSize: "100",
+		},
+		{
+			name:    "maximum size",
+			enabled: true,
+
+			maxSize: "9223372036854775807",
+		},
+	}
+
+	var (
+		downloaderCreator = func(_ ahp.DownloaderConfig) (ahp.Downloader, error) {
+			return ahp.NewMockDownloaderWithParser(bytes.NewBufferString(``)), nil
+		}
+
+		parserCreator = func(cfg ahp.ParserConfig) ahp.Parser {
+			return xpath.NewParser(cfg.Expression, xpath.WithCharset(cfg.Charset))
+		}
+	)
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			var (
+				input = bytes.NewBufferString(`{"content":"H4sIAAAAAAACA7MpzbGzycm0S8pJBCIbfSDTRh8oBgDAYNldGAAAAA==",` +
+					`"content-format":"base64","content-encoding":"auto","max-decompressed-size":` + test.maxSize +
+					`,"xpath-expression":"//ul/li"}`)
+
+				actual = &bytes.Buffer{}
+			)
+
+			if err := NewParseService(downloaderCreator, parserCreator).Parse(actual, input); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.JSONEq(t, `{"success":true,"nodes":["<li>blabla</li>"],"charset":"utf-8"}`, actual.String())
+		})
+	}
+}
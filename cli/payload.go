@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+// PayloadFormat defines how binary data is carried in a JSON string.
+type PayloadFormat string
+
+const (
+	PayloadFormatRaw    PayloadFormat = "raw"
+	PayloadFormatBase64 PayloadFormat = "base64"
+)
+
+var ErrInvalidPayloadFormat = errors.New("invalid payload format")
+
+func (f PayloadFormat) Decode(s string) (b []byte, err error) {
+	switch f {
+	case "", PayloadFormatRaw:
+		return []byte(s), nil
+	case PayloadFormatBase64:
+		if b, err = base64.StdEncoding.DecodeString(s); err != nil {
+			return nil, err
+		}
+
+		return b, nil
+	}
+
+	return nil, ErrInvalidPayloadFormat
+}
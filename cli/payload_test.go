@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadFormat_Decode(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		format PayloadFormat
+		in     string
+
+		wantErr  bool
+		expected []byte
+	}{
+		{
+			name:    "default format",
+			enabled: true,
+
+			in: "GET page-42\n",
+
+			expected: []byte("GET page-42\n"),
+		},
+		{
+			name:    "raw",
+			enabled: true,
+
+			format: PayloadFormatRaw,
+			in:     "GET page-42\n",
+
+			expected: []byte("GET page-42\n"),
+		},
+		{
+			name:    "base64",
+			enabled: true,
+
+			format: PayloadFormatBase64,
+			in:     "R0VUIHBhZ2UtNDIK",
+
+			expected: []byte("GET page-42\n"),
+		},
+		{
+			name:    "invalid base64",
+			enabled: true,
+
+			format: PayloadFormatBase64,
+			in:     "GET page-42",
+
+			wantErr: true,
+		},
+		{
+			name:    "invalid format",
+			enabled: true,
+
+			format: "hex",
+			in:     "474554",
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actual, err := test.format.Decode(test.in)
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
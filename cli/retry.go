@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"errors"
+	"time"
+
+	"github.com/morozovcookie/afihtmlparser/retry"
+)
+
+const (
+	DefaultRetryInitialBackoff = Duration(100 * time.Millisecond)
+	DefaultRetryMaxBackoff     = Duration(5 * time.Second)
+	DefaultRetryMultiplier     = 2
+	DefaultRetryJitter         = 0.2
+)
+
+var (
+	ErrInvalidRetryAttempts   = errors.New("input validation error: retry attempts must be positive")
+	ErrInvalidRetryMultiplier = errors.New("input validation error: retry multiplier must not be less than 1")
+	ErrInvalidRetryJitter     = errors.New("input validation error: retry jitter must be between 0 and 1")
+)
+
+// Retry describes how transient download failures are retried.
+type Retry struct {
+	Attempts       int      `json:"attempts"`
+	InitialBackoff Duration `json:"initial-backoff"`
+	MaxBackoff     Duration `json:"max-backoff"`
+	Multiplier     float64  `json:"multiplier"`
+	Jitter         *float64 `json:"jitter"`
+}
+
+func (r *Retry) Validate() (err error) {
+	if r.Attempts <= 0 {
+		return ErrInvalidRetryAttempts
+	}
+
+	if r.Multiplier != 0 && r.Multiplier < 1 {
+		return ErrInvalidRetryMultiplier
+	}
+
+	if r.Jitter != nil && (*r.Jitter < 0 || *r.Jitter > 1) {
+		return ErrInvalidRetryJitter
+	}
+
+	return nil
+}
+
+// Backoff returns the backoff with defaults applied to the omitted fields.
+func (r *Retry) Backoff() retry.Backoff {
+	backoff := retry.Backoff{
+		Initial:    DefaultRetryInitialBackoff.Duration(),
+		Max:        DefaultRetryMaxBackoff.Duration(),
+		Multiplier: DefaultRetryMultiplier,
+		Jitter:     DefaultRetryJitter,
+	}
+
+	if r.InitialBackoff > 0 {
+		backoff.Initial = r.InitialBackoff.Duration()
+	}
+
+	if r.MaxBackoff > 0 {
+		backoff.Max = r.MaxBackoff.Duration()
+	}
+
+	if r.Multiplier != 0 {
+		backoff.Multiplier = r.Multiplier
+	}
+
+	if r.Jitter != nil {
+		backoff.Jitter = *r.Jitter
+	}
+
+	return backoff
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/morozovcookie/afihtmlparser/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry_Validate(t *testing.T) {
+	var (
+		validJitter   = 0.5
+		invalidJitter = 1.5
+	)
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		retry *Retry
+
+		wantErr  bool
+		expected error
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			retry: &Retry{
+				Attempts:   3,
+				Multiplier: 1.5,
+				Jitter:     &validJitter,
+			},
+		},
+		{
+			name:    "zero attempts",
+			enabled: true,
+
+			retry: &Retry{},
+
+			wantErr:  true,
+			expected: ErrInvalidRetryAttempts,
+		},
+		{
+			name:    "invalid multiplier",
+			enabled: true,
+
+			retry: &Retry{
+				Attempts:   3,
+				Multiplier: 0.5,
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidRetryMultiplier,
+		},
+		{
+			name:    "invalid jitter",
+			enabled: true,
+
+			retry: &Retry{
+				Attempts: 3,
+				Jitter:   &invalidJitter,
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidRetryJitter,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actual := test.retry.Validate()
+			if (actual != nil) != test.wantErr {
+				t.Error(actual)
+				t.FailNow()
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestRetry_Backoff(t *testing.T) {
+	noJitter := 0.0
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		retry *Retry
+
+		expected retry.Backoff
+	}{
+		{
+			name:    "defaults",
+			enabled: true,
+
+			retry: &Retry{
+				Attempts: 3,
+			},
+
+			expected: retry.Backoff{
+				Initial:    100 * time.Millisecond,
+				Max:        5 * time.Second,
+				Multiplier: 2,
+				Jitter:     0.2,
+			},
+		},
+		{
+			name:    "explicit values",
+			enabled: true,
+
+			retry: &Retry{
+				Attempts:       3,
+				InitialBackoff: Duration(time.Second),
+				MaxBackoff:     Duration(time.Minute),
+				Multiplier:     3,
+				Jitter:         &noJitter,
+			},
+
+			expected: retry.Backoff{
+				Initial:    time.Second,
+				Max:        time.Minute,
+				Multiplier: 3,
+			},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			assert.Equal(t, test.expected, test.retry.Backoff())
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"errors"
+)
+
+const DefaultSRVProto = "tcp"
+
+var ErrEmptySRVName = errors.New("input validation error: empty srv name")
+
+// SRV describes the DNS SRV lookup of the candidate addresses, e.g. _html._tcp.mydomain.zone.
+// Scheme is prepended to the resolved "host:port" addresses.
+type SRV struct {
+	Service string `json:"service"`
+	Proto   string `json:"proto"`
+	Name    string `json:"name"`
+	Scheme  string `json:"scheme"`
+}
+
+func (s *SRV) Validate() (err error) {
+	if s.Name == "" {
+		return ErrEmptySRVName
+	}
+
+	return nil
+}
+
+func (s *SRV) proto() string {
+	if s.Proto == "" {
+		return DefaultSRVProto
+	}
+
+	return s.Proto
+}
+
+func (s *SRV) address(hostPort string) string {
+	if s.Scheme == "" {
+		return hostPort
+	}
+
+	return s.Scheme + "://" + hostPort
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+var (
+	ErrTLSCertificateWithoutKey = errors.New("input validation error: tls cert-file and key-file must be set together")
+	ErrInvalidTLSVersion        = errors.New("input validation error: invalid tls min-version")
+	ErrTLSWithoutTLSAddress     = errors.New("input validation error: tls requires tls or https addresses")
+	ErrTLSConfiguration         = errors.New("tls configuration error")
+)
+
+// DefaultTLSMinVersion is the minimum TLS version when min-version is omitted, the same the tls
+// transport assumes without the tls options.
+const DefaultTLSMinVersion = "1.2"
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLS describes the client side of a TLS connection. CertFile and KeyFile enable mutual TLS.
+type TLS struct {
+	ServerName         string `json:"server-name"`
+	CAFile             string `json:"ca-file"`
+	CertFile           string `json:"cert-file"`
+	KeyFile            string `json:"key-file"`
+	MinVersion         string `json:"min-version"`
+	InsecureSkipVerify bool   `json:"insecure-skip-verify"`
+}
+
+func (t *TLS) Validate() (err error) {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return ErrTLSCertificateWithoutKey
+	}
+
+	if _, ok := tlsVersions[t.MinVersion]; t.MinVersion != "" && !ok {
+		return ErrInvalidTLSVersion
+	}
+
+	return nil
+}
+
+// Config loads the certificates and builds the tls.Config.
+func (t *TLS) Config() (cfg *tls.Config, err error) {
+	minVersion := t.MinVersion
+	if minVersion == "" {
+		minVersion = DefaultTLSMinVersion
+	}
+
+	cfg = &tls.Config{
+		ServerName:         t.ServerName,
+		MinVersion:         tlsVersions[minVersion],
+		InsecureSkipVerify: t.InsecureSkipVerify, //nolint:gosec
+	}
+
+	if t.CAFile != "" {
+		pem, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrTLSConfiguration, err)
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		if ok := cfg.RootCAs.AppendCertsFromPEM(pem); !ok {
+			return nil, fmt.Errorf("%w: no certificates found in %s", ErrTLSConfiguration, t.CAFile)
+		}
+	}
+
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrTLSConfiguration, err)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
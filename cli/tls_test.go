@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLS_Validate(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		tls *TLS
+
+		wantErr  bool
+		expected error
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			tls: &TLS{
+				ServerName: "mydomain.zone",
+				CertFile:   "client.pem",
+				KeyFile:    "client.key",
+				MinVersion: "1.2",
+			},
+		},
+		{
+			name:    "certificate without key",
+			enabled: true,
+
+			tls: &TLS{
+				CertFile: "client.pem",
+			},
+
+			wantErr:  true,
+			expected: ErrTLSCertificateWithoutKey,
+		},
+		{
+			name:    "invalid min version",
+			enabled: true,
+
+			tls: &TLS{
+				MinVersion: "1.4",
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidTLSVersion,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actual := test.tls.Validate()
+			if (actual != nil) != test.wantErr {
+				t.Error(actual)
+				t.FailNow()
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestTLS_Config(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	emptyCAFile := filepath.Join(dir, "empty.pem")
+	if err = ioutil.WriteFile(emptyCAFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		tls *TLS
+
+		wantErr  bool
+		expected *tls.Config
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			tls: &TLS{
+				ServerName:         "mydomain.zone",
+				MinVersion:         "1.3",
+				InsecureSkipVerify: true,
+			},
+
+			expected: &tls.Config{
+				ServerName:         "mydomain.zone",
+				MinVersion:         tls.VersionTLS13,
+				InsecureSkipVerify: true, //nolint:gosec
+			},
+		},
+		{
+			name:    "default min version",
+			enabled: true,
+
+			tls: &TLS{},
+
+			expected: &tls.Config{
+				MinVersion: tls.VersionTLS12,
+			},
+		},
+		{
+			name:    "missing ca file",
+			enabled: true,
+
+			tls: &TLS{
+				CAFile: filepath.Join(dir, "missing.pem"),
+			},
+
+			wantErr: true,
+		},
+		{
+			name:    "ca file without certificates",
+			enabled: true,
+
+			tls: &TLS{
+				CAFile: emptyCAFile,
+			},
+
+			wantErr: true,
+		},
+		{
+			name:    "missing key pair",
+			enabled: true,
+
+			tls: &TLS{
+				CertFile: filepath.Join(dir, "client.pem"),
+				KeyFile:  filepath.Join(dir, "client.key"),
+			},
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actual, err := test.tls.Config()
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
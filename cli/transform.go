@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TransformType defines the post-processing step applied to the results of an expression.
+type TransformType string
+
+const (
+	TransformTypeTrim         TransformType = "trim"
+	TransformTypeLowercase    TransformType = "lowercase"
+	TransformTypeRegexExtract TransformType = "regex-extract"
+	TransformTypeRegexReplace TransformType = "regex-replace"
+	TransformTypeReplace      TransformType = "replace"
+	TransformTypeToInt        TransformType = "to-int"
+	TransformTypeToFloat      TransformType = "to-float"
+	// TransformTypeToDate parses the date in the layout of the time package, e.g. "02.01.2006",
+	// and returns it in RFC 3339.
+	TransformTypeToDate         TransformType = "to-date"
+	TransformTypeDefaultIfEmpty TransformType = "default-if-empty"
+)
+
+var (
+	ErrUnknownTransform         = errors.New("input validation error: unknown transform")
+	ErrInvalidTransformPattern  = errors.New("input validation error: invalid transform pattern")
+	ErrInvalidTransformGroup    = errors.New("input validation error: transform group is out of range")
+	ErrEmptyTransformOld        = errors.New("input validation error: empty transform old string")
+	ErrEmptyTransformLayout     = errors.New("input validation error: empty transform layout")
+	ErrTransformsOfUnknownField = errors.New("input validation error: transforms of unknown field")
+	ErrTransform                = errors.New("transform error")
+)
+
+var (
+	errNotString      = errors.New("value is not a string")
+	errNotFiniteFloat = errors.New("value is not a finite number")
+	errNotInteger     = errors.New("value is not an integer in range")
+)
+
+// Transform is a step of the post-processing of the results of an expression.
+type Transform struct {
+	Type TransformType `json:"type"`
+	// Pattern is the regular expression of the regex-extract and regex-replace transforms.
+	Pattern string `json:"pattern"`
+	// Group is the capture group extracted by regex-extract, the whole match by default.
+	Group int `json:"group"`
+	// Old is the string replaced by the replace transform.
+	Old string `json:"old"`
+	// Replacement replaces the matches of regex-replace, where $1 stands for the first group, and
+	// the Old string of replace.
+	Replacement string `json:"replacement"`
+	// Layout is the layout of the dates parsed by to-date.
+	Layout string `json:"layout"`
+	// Value replaces the empty strings in default-if-empty.
+	Value string `json:"value"`
+
+	// re is the Pattern compiled by Validate.
+	re *regexp.Regexp
+}
+
+// Validate checks the transform and compiles its pattern once for all the values it applies to.
+func (t *Transform) Validate() (err error) {
+	switch t.Type {
+	case TransformTypeTrim, TransformTypeLowercase, TransformTypeToInt, TransformTypeToFloat,
+		TransformTypeDefaultIfEmpty:
+	case TransformTypeRegexExtract, TransformTypeRegexReplace:
+		if t.re, err = t.regexp(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTransformPattern, err)
+		}
+
+		if t.Group < 0 || t.Group > t.re.NumSubexp() {
+			return ErrInvalidTransformGroup
+		}
+	case TransformTypeReplace:
+		if t.Old == "" {
+			return ErrEmptyTransformOld
+		}
+	case TransformTypeToDate:
+		if t.Layout == "" {
+			return ErrEmptyTransformLayout
+		}
+	default:
+		return ErrUnknownTransform
+	}
+
+	return nil
+}
+
+// regexp returns the compiled pattern, compiling it when the transform isn't validated.
+func (t Transform) regexp() (*regexp.Regexp, error) {
+	if t.re != nil {
+		return t.re, nil
+	}
+
+	if t.Pattern == "" {
+		return nil, errors.New("empty pattern")
+	}
+
+	return regexp.Compile(t.Pattern)
+}
+
+// Apply returns the value transformed. The string transforms apply to strings only, the
+// conversions also accept the numbers.
+func (t Transform) Apply(v interface{}) (_ interface{}, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("%w: %s: %v", ErrTransform, t.Type, err)
+		}
+	}()
+
+	switch v := v.(type) {
+	case string:
+		return t.apply(v)
+	case float64, int64:
+		return t.convert(v)
+	}
+
+	if t.Type == TransformTypeDefaultIfEmpty {
+		return v, nil
+	}
+
+	return nil, errNotString
+}
+
+func (t Transform) apply(s string) (interface{}, error) {
+	switch t.Type {
+	case TransformTypeTrim:
+		return strings.TrimSpace(s), nil
+	case TransformTypeLowercase:
+		return strings.ToLower(s), nil
+	case TransformTypeRegexExtract:
+		re, err := t.regexp()
+		if err != nil {
+			return nil, err
+		}
+
+		if match := re.FindStringSubmatch(s); match != nil {
+			return match[t.Group], nil
+		}
+
+		return "", nil
+	case TransformTypeRegexReplace:
+		re, err := t.regexp()
+		if err != nil {
+			return nil, err
+		}
+
+		return re.ReplaceAllString(s, t.Replacement), nil
+	case TransformTypeReplace:
+		return strings.ReplaceAll(s, t.Old, t.Replacement), nil
+	case TransformTypeToInt:
+		return strconv.ParseInt(s, 10, 64)
+	case TransformTypeToFloat:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, errNotFiniteFloat
+		}
+
+		return f, nil
+	case TransformTypeToDate:
+		d, err := time.Parse(t.Layout, s)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.Format(time.RFC3339), nil
+	case TransformTypeDefaultIfEmpty:
+		if s == "" {
+			return t.Value, nil
+		}
+
+		return s, nil
+	}
+
+	return nil, ErrUnknownTransform
+}
+
+// convert applies the transform to the number, e.g. the result of count() or a previous conversion.
+func (t Transform) convert(v interface{}) (interface{}, error) {
+	switch t.Type {
+	case TransformTypeToInt:
+		if f, ok := v.(float64); ok {
+			// The range is [-2^63, 2^63) as 2^63-1 isn't a float64.
+			if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return nil, errNotInteger
+			}
+
+			return int64(f), nil
+		}
+	case TransformTypeToFloat:
+		if i, ok := v.(int64); ok {
+			return float64(i), nil
+		}
+	case TransformTypeDefaultIfEmpty:
+	default:
+		return nil, errNotString
+	}
+
+	return v, nil
+}
+
+// Transforms is the post-processing pipeline applying the transforms in order.
+type Transforms []Transform
+
+func (tt Transforms) Validate() (err error) {
+	for i := range tt {
+		if err = tt[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tt Transforms) hasDefault() bool {
+	for _, t := range tt {
+		if t.Type == TransformTypeDefaultIfEmpty {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply returns the value passed through all the transforms.
+func (tt Transforms) Apply(v interface{}) (_ interface{}, err error) {
+	for _, t := range tt {
+		if v, err = t.Apply(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
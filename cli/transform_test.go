@@ -0,0 +1,314 @@
+package cli
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransform_Validate(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		transform Transform
+
+		wantErr  bool
+		expected error
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			transform: Transform{
+				Type:    TransformTypeRegexExtract,
+				Pattern: `(\d+)\.(\d+)`,
+				Group:   2,
+			},
+		},
+		{
+			name:    "unknown transform",
+			enabled: true,
+
+			transform: Transform{
+				Type: "uppercase",
+			},
+
+			wantErr:  true,
+			expected: ErrUnknownTransform,
+		},
+		{
+			name:    "empty pattern",
+			enabled: true,
+
+			transform: Transform{
+				Type: TransformTypeRegexReplace,
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidTransformPattern,
+		},
+		{
+			name:    "invalid pattern",
+			enabled: true,
+
+			transform: Transform{
+				Type:    TransformTypeRegexExtract,
+				Pattern: `(\d+`,
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidTransformPattern,
+		},
+		{
+			name:    "group out of range",
+			enabled: true,
+
+			transform: Transform{
+				Type:    TransformTypeRegexExtract,
+				Pattern: `(\d+)`,
+				Group:   2,
+			},
+
+			wantErr:  true,
+			expected: ErrInvalidTransformGroup,
+		},
+		{
+			name:    "empty old string",
+			enabled: true,
+
+			transform: Transform{
+				Type: TransformTypeReplace,
+			},
+
+			wantErr:  true,
+			expected: ErrEmptyTransformOld,
+		},
+		{
+			name:    "empty layout",
+			enabled: true,
+
+			transform: Transform{
+				Type: TransformTypeToDate,
+			},
+
+			wantErr:  true,
+			expected: ErrEmptyTransformLayout,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actual := test.transform.Validate()
+			if (actual != nil) != test.wantErr {
+				t.Error(actual)
+				t.FailNow()
+			}
+
+			assert.True(t, errors.Is(actual, test.expected))
+		})
+	}
+}
+
+func TestTransforms_Validate(t *testing.T) {
+	tt := Transforms{
+		{Type: TransformTypeTrim},
+		{Type: TransformTypeRegexExtract, Pattern: `\d+`},
+	}
+
+	if err := tt.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, tt[0].re)
+	assert.NotNil(t, tt[1].re)
+
+	actual, err := tt.Apply(" 42 RUB ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "42", actual)
+}
+
+func TestTransforms_Apply(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		transforms Transforms
+		value      interface{}
+
+		wantErr bool
+
+		expected interface{}
+	}{
+		{
+			name:    "trim and lowercase",
+			enabled: true,
+
+			transforms: Transforms{
+				{Type: TransformTypeTrim},
+				{Type: TransformTypeLowercase},
+			},
+			value: " \n\tIn Stock ",
+
+			expected: "in stock",
+		},
+		{
+			name:    "price",
+			enabled: true,
+
+			transforms: Transforms{
+				{Type: TransformTypeRegexExtract, Pattern: `([\d ]+,\d+) RUB`, Group: 1},
+				{Type: TransformTypeReplace, Old: " ", Replacement: ""},
+				{Type: TransformTypeReplace, Old: ",", Replacement: "."},
+				{Type: TransformTypeToFloat},
+			},
+			value: "Price: 1 299,90 RUB",
+
+			expected: 1299.9,
+		},
+		{
+			name:    "regex replace",
+			enabled: true,
+
+			transforms: Transforms{
+				{Type: TransformTypeRegexReplace, Pattern: `(\w+)@(\w+)`, Replacement: "$2 at $1"},
+			},
+			value: "user@example",
+
+			expected: "example at user",
+		},
+		{
+			name:    "default if nothing extracted",
+			enabled: true,
+
+			transforms: Transforms{
+				{Type: TransformTypeRegexExtract, Pattern: `\d+`},
+				{Type: TransformTypeDefaultIfEmpty, Value: "0"},
+				{Type: TransformTypeToInt},
+			},
+			value: "out of stock",
+
+			expected: int64(0),
+		},
+		{
+			name:    "date",
+			enabled: true,
+
+			transforms: Transforms{
+				{Type: TransformTypeToDate, Layout: "02.01.2006 15:04"},
+			},
+			value: "31.12.2020 23:59",
+
+			expected: "2020-12-31T23:59:00Z",
+		},
+		{
+			name:    "count to int",
+			enabled: true,
+
+			transforms: Transforms{
+				{Type: TransformTypeToInt},
+			},
+			value: float64(3),
+
+			expected: int64(3),
+		},
+		{
+			name:    "fractional number to int",
+			enabled: true,
+
+			transforms: Transforms{
+				{Type: TransformTypeToInt},
+			},
+			value: 2.5,
+
+			wantErr: true,
+		},
+		{
+			name:    "number out of int range",
+			enabled: true,
+
+			transforms: Transforms{
+				{Type: TransformTypeToInt},
+			},
+			value: 1e19,
+
+			wantErr: true,
+		},
+		{
+			name:    "infinity to int",
+			enabled: true,
+
+			transforms: Transforms{
+				{Type: TransformTypeToInt},
+			},
+			value: math.Inf(1),
+
+			wantErr: true,
+		},
+		{
+			name:    "invalid int",
+			enabled: true,
+
+			transforms: Transforms{
+				{Type: TransformTypeToInt},
+			},
+			value: "12.5",
+
+			wantErr: true,
+		},
+		{
+			name:    "not a finite float",
+			enabled: true,
+
+			transforms: Transforms{
+				{Type: TransformTypeToFloat},
+			},
+			value: "NaN",
+
+			wantErr: true,
+		},
+		{
+			name:    "string transform of number",
+			enabled: true,
+
+			transforms: Transforms{
+				{Type: TransformTypeToInt},
+				{Type: TransformTypeTrim},
+			},
+			value: "42",
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actual, err := test.transforms.Apply(test.value)
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if test.wantErr {
+				assert.True(t, errors.Is(err, ErrTransform))
+
+				return
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
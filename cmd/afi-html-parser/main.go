@@ -3,26 +3,62 @@ package main
 import (
 	"fmt"
 	"os"
-	"time"
+	"strings"
 
 	ahp "github.com/morozovcookie/afihtmlparser"
 	"github.com/morozovcookie/afihtmlparser/cli"
+	"github.com/morozovcookie/afihtmlparser/css"
+	"github.com/morozovcookie/afihtmlparser/file"
+	_ "github.com/morozovcookie/afihtmlparser/http"
+	"github.com/morozovcookie/afihtmlparser/jsonpath"
+	"github.com/morozovcookie/afihtmlparser/policy"
 	"github.com/morozovcookie/afihtmlparser/tcp"
 	"github.com/morozovcookie/afihtmlparser/xpath"
 )
 
 func main() {
-	var (
-		downloaderCreator = func(address string, timeout time.Duration) ahp.Downloader {
-			return tcp.NewDownloader(address, timeout)
+	parserCreator := func(cfg ahp.ParserConfig) ahp.Parser {
+		switch cfg.SelectorType {
+		case ahp.SelectorTypeCSS:
+			return css.NewParser(cfg.Expression, css.WithCharset(cfg.Charset), css.WithRenderMode(cfg.RenderMode),
+				css.WithEscaping(cfg.EscapeHTML))
+		case ahp.SelectorTypeJSONPath:
+			return jsonpath.NewParser(cfg.Expression, jsonpath.WithCharset(cfg.Charset))
 		}
 
-		parserCreator = func(expression string) ahp.Parser {
-			return xpath.NewParser(expression)
+		if cfg.DocumentType == ahp.DocumentTypeXML {
+			return xpath.NewParser(cfg.Expression, xpath.WithXML(cfg.Namespaces),
+				xpath.WithRenderMode(cfg.RenderMode))
 		}
-	)
 
-	if err := cli.NewParseService(downloaderCreator, parserCreator).Parse(os.Stdout, os.Stdin); err != nil {
+		return xpath.NewParser(cfg.Expression, xpath.WithCharset(cfg.Charset), xpath.WithRenderMode(cfg.RenderMode),
+			xpath.WithEscaping(cfg.EscapeHTML))
+	}
+
+	if root := os.Getenv("AHP_FILE_ROOT"); root != "" {
+		ahp.RegisterDownloader(file.Scheme, file.NewFactory(root))
+	}
+
+	if sockets := os.Getenv("AHP_UNIX_SOCKETS"); sockets != "" {
+		factory := tcp.NewUnixFactory(strings.Split(sockets, ","))
+
+		ahp.RegisterDownloader(tcp.UnixScheme, factory)
+		ahp.RegisterDownloader(tcp.UnixPacketScheme, factory)
+	}
+
+	var opts []cli.Option
+
+	networkPolicy, err := policy.FromEnv()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "network policy error: %v \n", err)
+		os.Exit(1)
+	}
+
+	if networkPolicy != nil {
+		opts = append(opts, cli.WithNetworkPolicy(networkPolicy))
+	}
+
+	if err = cli.NewParseService(ahp.CreateDownloader, parserCreator, opts...).Parse(os.Stdout, os.Stdin); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "parse error: %v \n", err)
 	}
 }
@@ -0,0 +1,90 @@
+package afihtmlparser
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ContextDownloader is a Downloader which stops downloading once the context is done.
+type ContextDownloader interface {
+	DownloadContext(ctx context.Context, contentLength int64, timeout time.Duration,
+		callback DownloadCallback) (err error)
+}
+
+// ContextParser is a Parser which stops parsing once the context is done.
+type ContextParser interface {
+	ParseContext(ctx context.Context, r io.Reader) (nodes []string, err error)
+}
+
+// DownloaderWithContext returns d itself when it supports contexts. Otherwise the returned adapter
+// checks the context before downloading and before handing the content to the callback.
+func DownloaderWithContext(d Downloader) ContextDownloader {
+	if cd, ok := d.(ContextDownloader); ok {
+		return cd
+	}
+
+	return &contextDownloader{d: d}
+}
+
+type contextDownloader struct {
+	d Downloader
+}
+
+func (cd *contextDownloader) DownloadContext(ctx context.Context, contentLength int64, timeout time.Duration,
+	callback DownloadCallback) (err error) {
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	return cd.d.Download(contentLength, timeout, func(r io.Reader) (err error) {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		return callback(r)
+	})
+}
+
+// ParserWithContext returns p itself when it supports contexts. Otherwise the returned adapter
+// checks the context before parsing.
+func ParserWithContext(p Parser) ContextParser {
+	if cp, ok := p.(ContextParser); ok {
+		return cp
+	}
+
+	return &contextParser{p: p}
+}
+
+type contextParser struct {
+	p Parser
+}
+
+func (cp *contextParser) ParseContext(ctx context.Context, r io.Reader) (nodes []string, err error) {
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return cp.p.Parse(r)
+}
+
+// ContextReader fails reading with the context error once the context is done.
+type ContextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func NewContextReader(ctx context.Context, r io.Reader) *ContextReader {
+	return &ContextReader{
+		ctx: ctx,
+		r:   r,
+	}
+}
+
+func (cr *ContextReader) Read(p []byte) (n int, err error) {
+	if err = cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return cr.r.Read(p)
+}
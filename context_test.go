@@ -0,0 +1,140 @@
+package afihtmlparser
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloaderWithContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		ctx context.Context
+
+		wantErr        bool
+		expectedCalled bool
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			ctx: context.Background(),
+
+			expectedCalled: true,
+		},
+		{
+			name:    "canceled context",
+			enabled: true,
+
+			ctx: canceled,
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			var (
+				downloader = NewMockDownloaderWithParser(bytes.NewBufferString(`<li>blabla</li>`))
+				called     bool
+			)
+
+			err := DownloaderWithContext(downloader).DownloadContext(test.ctx, 10, time.Second, func(_ io.Reader) error {
+				called = true
+
+				return nil
+			})
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			assert.Equal(t, test.expectedCalled, called)
+		})
+	}
+}
+
+func TestParserWithContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		ctx context.Context
+
+		wantErr  bool
+		expected []string
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			ctx: context.Background(),
+
+			expected: []string{`<li>blabla</li>`},
+		},
+		{
+			name:    "canceled context",
+			enabled: true,
+
+			ctx: canceled,
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			var (
+				r      = bytes.NewBufferString(`<li>blabla</li>`)
+				parser = &MockParser{}
+			)
+
+			parser.On("Parse", r).Return([]string{`<li>blabla</li>`}, nil)
+
+			actual, err := ParserWithContext(parser).ParseContext(test.ctx, r)
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestContextReader_Read(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := NewContextReader(ctx, bytes.NewBufferString(`<li>blabla</li>`))
+
+	b := make([]byte, 4)
+	if _, err := r.Read(b); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+
+	_, err := ioutil.ReadAll(r)
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,147 @@
+package css
+
+import (
+	"context"
+	"io"
+
+	"github.com/andybalholm/cascadia"
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/morozovcookie/afihtmlparser/charset"
+	"github.com/morozovcookie/afihtmlparser/render"
+	"golang.org/x/net/html"
+)
+
+// Parser selects the nodes of HTML documents matching a CSS3 selector group, e.g.
+// "ul > li:nth-child(2n+1):not(.hidden), a[href^='https']".
+type Parser struct {
+	selector string
+	label    string
+	mode     ahp.RenderMode
+	escaped  bool
+
+	charset string
+}
+
+type Option func(p *Parser)
+
+// WithCharset sets the charset of the documents. By default it's detected from the byte order
+// mark or the <meta> tag of every document.
+func WithCharset(label string) Option {
+	return func(p *Parser) {
+		p.label = label
+	}
+}
+
+// WithRenderMode sets how the matching nodes are returned, ahp.RenderModeOuterHTML by default.
+// CSS selectors don't select attributes, so ahp.RenderModeAttribute isn't supported.
+func WithRenderMode(mode ahp.RenderMode) Option {
+	return func(p *Parser) {
+		p.mode = mode
+	}
+}
+
+// WithEscaping renders the HTML with render.EscapedHTML instead of render.HTML.
+func WithEscaping(escaped bool) Option {
+	return func(p *Parser) {
+		p.escaped = escaped
+	}
+}
+
+func NewParser(selector string, opts ...Option) *Parser {
+	p := &Parser{
+		selector: selector,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Charset returns the canonical name of the charset the last parsed document was transcoded from.
+func (p *Parser) Charset() string {
+	return p.charset
+}
+
+func (p *Parser) Parse(r io.Reader) ([]string, error) {
+	return p.ParseContext(context.Background(), r)
+}
+
+// ParseContext is Parse which stops reading the document and rendering the nodes once the
+// context is done. The document is transcoded to UTF-8 before parsing.
+func (p *Parser) ParseContext(ctx context.Context, r io.Reader) ([]string, error) {
+	if _, err := cascadia.Compile(p.selector); err != nil {
+		return nil, err
+	}
+
+	doc, err := p.ParseDocument(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := doc.Evaluate(ctx, p.selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Nodes, nil
+}
+
+// ParseDocument parses the document once for evaluating several selectors. The document is
+// transcoded to UTF-8 before parsing.
+func (p *Parser) ParseDocument(ctx context.Context, r io.Reader) (ahp.Document, error) {
+	r, name, err := charset.NewReader(ahp.NewContextReader(ctx, r), p.label)
+	if err != nil {
+		return nil, err
+	}
+
+	p.charset = name
+
+	n, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &htmlDocument{root: n, mode: p.mode, escaped: p.escaped}, nil
+}
+
+type htmlDocument struct {
+	root    *html.Node
+	mode    ahp.RenderMode
+	escaped bool
+}
+
+func (d *htmlDocument) Evaluate(ctx context.Context, selector string) (result ahp.Result, err error) {
+	sel, err := cascadia.Compile(selector)
+	if err != nil {
+		return result, err
+	}
+
+	nn := sel.MatchAll(d.root)
+
+	result.Nodes = make([]string, 0, len(nn))
+
+	for _, n := range nn {
+		if err = ctx.Err(); err != nil {
+			return result, err
+		}
+
+		var node string
+		if node, err = d.render(n); err != nil {
+			return result, err
+		}
+
+		result.Nodes = append(result.Nodes, node)
+	}
+
+	return result, nil
+}
+
+func (d *htmlDocument) render(n *html.Node) (string, error) {
+	if d.escaped {
+		return render.EscapedHTML(n, d.mode)
+	}
+
+	return render.HTML(n, d.mode)
+}
@@ -0,0 +1,151 @@
+package css
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/stretchr/testify/assert"
+)
+
+const document = `<ul>
+	<li class="tag">Tag attributes</li>
+	<li>Make plain text</li>
+	<li class="tag hidden"><a href="https://example.com/page" hreflang="en-US">Links</a></li>
+	<li><a href="http://example.com/">Plain links</a></li>
+</ul>`
+
+func TestParser_Parse(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		selector string
+
+		wantErr bool
+
+		expectedNodes []string
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			selector: `ul > li.tag`,
+
+			expectedNodes: []string{
+				`<li class="tag">Tag attributes</li>`,
+				`<li class="tag hidden"><a href="https://example.com/page" hreflang="en-US">Links</a></li>`,
+			},
+		},
+		{
+			name:    "nth-child",
+			enabled: true,
+
+			selector: `li:nth-child(2n)`,
+
+			expectedNodes: []string{
+				`<li>Make plain text</li>`,
+				`<li><a href="http://example.com/">Plain links</a></li>`,
+			},
+		},
+		{
+			name:    "not",
+			enabled: true,
+
+			selector: `li.tag:not(.hidden)`,
+
+			expectedNodes: []string{
+				`<li class="tag">Tag attributes</li>`,
+			},
+		},
+		{
+			name:    "attribute operators",
+			enabled: true,
+
+			selector: `a[href^="https"][href$="/page"][href*="example"][hreflang|="en"]`,
+
+			expectedNodes: []string{
+				`<a href="https://example.com/page" hreflang="en-US">Links</a>`,
+			},
+		},
+		{
+			name:    "selector group in document order",
+			enabled: true,
+
+			selector: `a[href^="http:"], li:first-child`,
+
+			expectedNodes: []string{
+				`<li class="tag">Tag attributes</li>`,
+				`<a href="http://example.com/">Plain links</a>`,
+			},
+		},
+		{
+			name:    "empty nodes list",
+			enabled: true,
+
+			selector: `ol > li`,
+
+			expectedNodes: []string{},
+		},
+		{
+			name:    "invalid selector",
+			enabled: true,
+
+			selector: `li:nth-child(`,
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actualNodes, err := NewParser(test.selector).Parse(bytes.NewBufferString(document))
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			assert.Equal(t, test.expectedNodes, actualNodes)
+		})
+	}
+}
+
+func TestParser_ParseContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewParser(`ul > li`).ParseContext(ctx, bytes.NewBufferString(document))
+
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestParser_ParseCharset(t *testing.T) {
+	p := NewParser(`li`, WithCharset("koi8-r"))
+
+	actualNodes, err := p.Parse(bytes.NewBufferString("<li>\xf0\xd2\xc9\xd7\xc5\xd4</li>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{`<li>Привет</li>`}, actualNodes)
+	assert.Equal(t, "koi8-r", p.Charset())
+}
+
+func TestParser_ParseRenderMode(t *testing.T) {
+	actualNodes, err := NewParser(`li`, WithRenderMode(ahp.RenderModeText)).
+		Parse(bytes.NewBufferString("<ul><li> Tag\n\tattributes </li><li>Make <b>plain</b> text</li></ul>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{`Tag attributes`, `Make plain text`}, actualNodes)
+}
@@ -0,0 +1,156 @@
+package decompress
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+)
+
+var (
+	ErrUnknownEncoding = errors.New("unknown content encoding")
+	ErrTooLarge        = errors.New("decompressed content is larger than allowed size")
+	ErrCorrupted       = errors.New("corrupted compressed content")
+)
+
+// Encoding defines how the content is compressed.
+type Encoding string
+
+const (
+	EncodingNone    Encoding = ""
+	EncodingGzip    Encoding = "gzip"
+	EncodingDeflate Encoding = "deflate"
+	EncodingZlib    Encoding = "zlib"
+	// EncodingAuto detects gzip and zlib by their magic bytes, any other content is passed as is.
+	EncodingAuto Encoding = "auto"
+)
+
+// Downloader decompresses the content downloaded by the underlying downloader before handing it to
+// the callback. The decompressed content is limited to protect against decompression bombs.
+type Downloader struct {
+	d        ahp.ContextDownloader
+	encoding Encoding
+	limit    int64
+}
+
+func NewDownloader(d ahp.Downloader, encoding Encoding, limit int64) *Downloader {
+	return &Downloader{
+		d:        ahp.DownloaderWithContext(d),
+		encoding: encoding,
+		limit:    limit,
+	}
+}
+
+// Download passes contentLength, the size of the compressed content, to the underlying downloader.
+// The callback reader fails with ErrTooLarge once more than the limit is decompressed.
+func (d *Downloader) Download(contentLength int64, timeout time.Duration, callbackFn ahp.DownloadCallback) (err error) {
+	return d.DownloadContext(context.Background(), contentLength, timeout, callbackFn)
+}
+
+func (d *Downloader) DownloadContext(ctx context.Context, contentLength int64, timeout time.Duration,
+	callbackFn ahp.DownloadCallback) (err error) {
+	return d.d.DownloadContext(ctx, contentLength, timeout, func(r io.Reader) (err error) {
+		dr, err := NewReader(r, d.encoding)
+		if err != nil {
+			return err
+		}
+
+		defer dr.Close()
+
+		content := ahp.NewLimitedReader(dr, d.limit, ErrTooLarge)
+
+		if err = callbackFn(content); err != nil {
+			return err
+		}
+
+		// The rest of the content is still decompressed to verify its checksum and size.
+		_, err = io.Copy(ioutil.Discard, content)
+
+		return err
+	})
+}
+
+// NewReader returns the reader decompressing r.
+func NewReader(r io.Reader, encoding Encoding) (rc io.ReadCloser, err error) {
+	if encoding == EncodingAuto {
+		br := bufio.NewReader(r)
+		encoding = detect(br)
+		r = br
+	}
+
+	switch encoding {
+	case EncodingNone:
+		return ioutil.NopCloser(r), nil
+	case EncodingGzip:
+		if rc, err = gzip.NewReader(r); err != nil {
+			return nil, corrupted(err)
+		}
+
+		return &errorReader{rc: rc}, nil
+	case EncodingDeflate:
+		return &errorReader{rc: flate.NewReader(r)}, nil
+	case EncodingZlib:
+		if rc, err = zlib.NewReader(r); err != nil {
+			return nil, corrupted(err)
+		}
+
+		return &errorReader{rc: rc}, nil
+	}
+
+	return nil, ErrUnknownEncoding
+}
+
+// detect recognizes the gzip magic number and the zlib header of a deflate stream.
+func detect(br *bufio.Reader) Encoding {
+	header, _ := br.Peek(2)
+	if len(header) < 2 {
+		return EncodingNone
+	}
+
+	if header[0] == 0x1f && header[1] == 0x8b {
+		return EncodingGzip
+	}
+
+	if header[0]&0x0f == 8 && header[0]>>4 <= 7 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return EncodingZlib
+	}
+
+	return EncodingNone
+}
+
+// corrupted wraps the errors of the malformed compressed data, the errors of the underlying reader
+// are reported as is.
+func corrupted(err error) error {
+	var corruptInput flate.CorruptInputError
+
+	switch {
+	case errors.Is(err, gzip.ErrHeader), errors.Is(err, gzip.ErrChecksum),
+		errors.Is(err, zlib.ErrHeader), errors.Is(err, zlib.ErrChecksum), errors.Is(err, zlib.ErrDictionary),
+		errors.As(err, &corruptInput):
+		return fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+
+	return err
+}
+
+type errorReader struct {
+	rc io.ReadCloser
+}
+
+func (r *errorReader) Read(p []byte) (n int, err error) {
+	n, err = r.rc.Read(p)
+
+	return n, corrupted(err)
+}
+
+func (r *errorReader) Close() error {
+	return r.rc.Close()
+}
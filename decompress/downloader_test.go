@@ -0,0 +1,224 @@
+package decompress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func compress(t *testing.T, encoding Encoding, content []byte) []byte {
+	t.Helper()
+
+	var (
+		buf = &bytes.Buffer{}
+		w   io.WriteCloser
+		err error
+	)
+
+	switch encoding {
+	case EncodingGzip:
+		w = gzip.NewWriter(buf)
+	case EncodingZlib:
+		w = zlib.NewWriter(buf)
+	case EncodingDeflate:
+		if w, err = flate.NewWriter(buf, flate.DefaultCompression); err != nil {
+			t.Fatal(err)
+		}
+	default:
+		return content
+	}
+
+	if _, err = w.Write(content); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDownloader_Download(t *testing.T) {
+	content := []byte(`<ul><li>blabla</li></ul>`)
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		encoding   Encoding
+		compressed func(t *testing.T) []byte
+		limit      int64
+
+		wantErr     bool
+		expectedErr error
+	}{
+		{
+			name:    "gzip",
+			enabled: true,
+
+			encoding: EncodingGzip,
+			compressed: func(t *testing.T) []byte {
+				return compress(t, EncodingGzip, content)
+			},
+			limit: 100,
+		},
+		{
+			name:    "deflate",
+			enabled: true,
+
+			encoding: EncodingDeflate,
+			compressed: func(t *testing.T) []byte {
+				return compress(t, EncodingDeflate, content)
+			},
+			limit: 100,
+		},
+		{
+			name:    "zlib",
+			enabled: true,
+
+			encoding: EncodingZlib,
+			compressed: func(t *testing.T) []byte {
+				return compress(t, EncodingZlib, content)
+			},
+			limit: 100,
+		},
+		{
+			name:    "auto detected gzip",
+			enabled: true,
+
+			encoding: EncodingAuto,
+			compressed: func(t *testing.T) []byte {
+				return compress(t, EncodingGzip, content)
+			},
+			limit: 100,
+		},
+		{
+			name:    "auto detected zlib",
+			enabled: true,
+
+			encoding: EncodingAuto,
+			compressed: func(t *testing.T) []byte {
+				return compress(t, EncodingZlib, content)
+			},
+			limit: 100,
+		},
+		{
+			name:    "auto detected plain content",
+			enabled: true,
+
+			encoding: EncodingAuto,
+			compressed: func(_ *testing.T) []byte {
+				return content
+			},
+			limit: 100,
+		},
+		{
+			name:    "exact limit",
+			enabled: true,
+
+			encoding: EncodingGzip,
+			compressed: func(t *testing.T) []byte {
+				return compress(t, EncodingGzip, content)
+			},
+			limit: int64(len(content)),
+		},
+		{
+			name:    "decompressed content is too large",
+			enabled: true,
+
+			encoding: EncodingGzip,
+			compressed: func(t *testing.T) []byte {
+				return compress(t, EncodingGzip, bytes.Repeat([]byte{'a'}, 1<<20))
+			},
+			limit: 1 << 10,
+
+			wantErr:     true,
+			expectedErr: ErrTooLarge,
+		},
+		{
+			name:    "corrupted checksum",
+			enabled: true,
+
+			encoding: EncodingGzip,
+			compressed: func(t *testing.T) []byte {
+				b := compress(t, EncodingGzip, content)
+				b[len(b)-5] ^= 0xff
+
+				return b
+			},
+			limit: 100,
+
+			wantErr:     true,
+			expectedErr: ErrCorrupted,
+		},
+		{
+			name:    "truncated stream",
+			enabled: true,
+
+			encoding: EncodingZlib,
+			compressed: func(t *testing.T) []byte {
+				b := compress(t, EncodingZlib, content)
+
+				return b[:len(b)/2]
+			},
+			limit: 100,
+
+			wantErr:     true,
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "unknown encoding",
+			enabled: true,
+
+			encoding: "br",
+			compressed: func(_ *testing.T) []byte {
+				return content
+			},
+			limit: 100,
+
+			wantErr:     true,
+			expectedErr: ErrUnknownEncoding,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			var (
+				d      = ahp.NewMockDownloaderWithParser(bytes.NewReader(test.compressed(t)))
+				actual []byte
+			)
+
+			err := NewDownloader(d, test.encoding, test.limit).Download(0, time.Second, func(r io.Reader) (err error) {
+				actual, err = ioutil.ReadAll(r)
+
+				return err
+			})
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if test.wantErr {
+				assert.True(t, errors.Is(err, test.expectedErr), err)
+
+				return
+			}
+
+			assert.Equal(t, content, actual)
+		})
+	}
+}
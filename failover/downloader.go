@@ -0,0 +1,102 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+)
+
+var (
+	ErrNoCandidates        = errors.New("no candidate addresses")
+	ErrAllCandidatesFailed = errors.New("all candidate addresses failed")
+)
+
+// Order defines the order the candidates are tried in.
+type Order string
+
+const (
+	OrderSequential Order = "sequential"
+	OrderRandom     Order = "random"
+)
+
+// Candidate is an address together with the downloader serving it.
+type Candidate struct {
+	Address    string
+	Downloader ahp.Downloader
+}
+
+// Downloader tries the candidates one by one until one of them starts serving the content. Once
+// the content is handed to the callback, errors are returned as is without trying other candidates.
+type Downloader struct {
+	candidates []Candidate
+	order      Order
+
+	servedBy string
+}
+
+func NewDownloader(candidates []Candidate, order Order) *Downloader {
+	return &Downloader{
+		candidates: candidates,
+		order:      order,
+	}
+}
+
+func (d *Downloader) Download(contentLength int64, timeout time.Duration, callbackFn ahp.DownloadCallback) (err error) {
+	return d.DownloadContext(context.Background(), contentLength, timeout, callbackFn)
+}
+
+func (d *Downloader) DownloadContext(ctx context.Context, contentLength int64, timeout time.Duration,
+	callbackFn ahp.DownloadCallback) (err error) {
+	d.servedBy = ""
+
+	if len(d.candidates) == 0 {
+		return ErrNoCandidates
+	}
+
+	for _, c := range d.ordered() {
+		started := false
+
+		err = ahp.DownloaderWithContext(c.Downloader).DownloadContext(ctx, contentLength, timeout,
+			func(r io.Reader) error {
+				started = true
+
+				return callbackFn(r)
+			})
+		if err == nil || started {
+			d.servedBy = c.Address
+
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return err
+		}
+	}
+
+	return fmt.Errorf("%w: %v", ErrAllCandidatesFailed, err)
+}
+
+// ServedBy returns the address of the candidate which served the content of the last download.
+func (d *Downloader) ServedBy() string {
+	return d.servedBy
+}
+
+func (d *Downloader) ordered() []Candidate {
+	if d.order != OrderRandom {
+		return d.candidates
+	}
+
+	candidates := make([]Candidate, len(d.candidates))
+	copy(candidates, d.candidates)
+
+	rand.Shuffle(len(candidates), func(i, j int) { //nolint:gosec
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	return candidates
+}
@@ -0,0 +1,139 @@
+package failover
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func failingDownloader(err error) ahp.Downloader {
+	downloader := &ahp.MockDownloader{}
+
+	downloader.
+		On("Download", int64(10), time.Second, mock.AnythingOfType("afihtmlparser.DownloadCallback")).
+		Return(err)
+
+	return downloader
+}
+
+func TestDownloader_Download(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		candidates []Candidate
+		callback   ahp.DownloadCallback
+
+		wantErr          error
+		expectedServedBy string
+	}{
+		{
+			name:    "pass with first candidate",
+			enabled: true,
+
+			candidates: []Candidate{
+				{Address: "127.0.0.1:8080", Downloader: ahp.NewMockDownloaderWithParser(bytes.NewBufferString(`1`))},
+				{Address: "127.0.0.1:8081", Downloader: failingDownloader(errors.New("connection refused"))},
+			},
+			callback: func(_ io.Reader) error {
+				return nil
+			},
+
+			expectedServedBy: "127.0.0.1:8080",
+		},
+		{
+			name:    "pass with second candidate",
+			enabled: true,
+
+			candidates: []Candidate{
+				{Address: "127.0.0.1:8080", Downloader: failingDownloader(errors.New("connection refused"))},
+				{Address: "127.0.0.1:8081", Downloader: ahp.NewMockDownloaderWithParser(bytes.NewBufferString(`1`))},
+			},
+			callback: func(_ io.Reader) error {
+				return nil
+			},
+
+			expectedServedBy: "127.0.0.1:8081",
+		},
+		{
+			name:    "no failover after content is served",
+			enabled: true,
+
+			candidates: []Candidate{
+				{Address: "127.0.0.1:8080", Downloader: ahp.NewMockDownloaderWithParser(bytes.NewBufferString(`1`))},
+				{Address: "127.0.0.1:8081", Downloader: ahp.NewMockDownloaderWithParser(bytes.NewBufferString(`1`))},
+			},
+			callback: func(_ io.Reader) error {
+				return io.ErrUnexpectedEOF
+			},
+
+			wantErr:          io.ErrUnexpectedEOF,
+			expectedServedBy: "127.0.0.1:8080",
+		},
+		{
+			name:    "all candidates failed",
+			enabled: true,
+
+			candidates: []Candidate{
+				{Address: "127.0.0.1:8080", Downloader: failingDownloader(errors.New("connection refused"))},
+				{Address: "127.0.0.1:8081", Downloader: failingDownloader(errors.New("connection refused"))},
+			},
+
+			wantErr: ErrAllCandidatesFailed,
+		},
+		{
+			name:    "no candidates",
+			enabled: true,
+
+			wantErr: ErrNoCandidates,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			d := NewDownloader(test.candidates, OrderSequential)
+
+			err := d.Download(10, time.Second, test.callback)
+			if test.wantErr != nil {
+				assert.True(t, errors.Is(err, test.wantErr), err)
+			} else {
+				assert.Nil(t, err)
+			}
+
+			assert.Equal(t, test.expectedServedBy, d.ServedBy())
+		})
+	}
+}
+
+func TestDownloader_DownloadRandomOrder(t *testing.T) {
+	candidates := []Candidate{
+		{Address: "127.0.0.1:8080", Downloader: ahp.NewMockDownloaderWithParser(bytes.NewBufferString(`1`))},
+		{Address: "127.0.0.1:8081", Downloader: ahp.NewMockDownloaderWithParser(bytes.NewBufferString(`1`))},
+	}
+
+	var (
+		d      = NewDownloader(candidates, OrderRandom)
+		served = make(map[string]bool)
+	)
+
+	for i := 0; i < 100; i++ {
+		if err := d.Download(10, time.Second, func(_ io.Reader) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+
+		served[d.ServedBy()] = true
+	}
+
+	assert.Len(t, served, 2)
+	assert.Equal(t, "127.0.0.1:8080", candidates[0].Address)
+}
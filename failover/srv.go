@@ -0,0 +1,35 @@
+package failover
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Resolver looks up DNS SRV records, *net.Resolver implements it.
+type Resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error)
+}
+
+// LookupSRV returns the "host:port" addresses of the SRV records ordered by priority.
+func LookupSRV(ctx context.Context, resolver Resolver, service, proto, name string) (addresses []string, err error) {
+	_, records, err := resolver.LookupSRV(ctx, service, proto, name)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Priority < records[j].Priority
+	})
+
+	addresses = make([]string, 0, len(records))
+
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		addresses = append(addresses, net.JoinHostPort(host, strconv.Itoa(int(record.Port))))
+	}
+
+	return addresses, nil
+}
@@ -0,0 +1,76 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubResolver struct {
+	records []*net.SRV
+	err     error
+}
+
+func (r stubResolver) LookupSRV(_ context.Context, _, _, _ string) (string, []*net.SRV, error) {
+	return "", r.records, r.err
+}
+
+func TestLookupSRV(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		resolver Resolver
+
+		wantErr  bool
+		expected []string
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			resolver: stubResolver{
+				records: []*net.SRV{
+					{Target: "backup.mydomain.zone.", Port: 9000, Priority: 20},
+					{Target: "primary.mydomain.zone.", Port: 8080, Priority: 10},
+					{Target: "fe80::1", Port: 8081, Priority: 10},
+				},
+			},
+
+			expected: []string{
+				"primary.mydomain.zone:8080",
+				"[fe80::1]:8081",
+				"backup.mydomain.zone:9000",
+			},
+		},
+		{
+			name:    "lookup error",
+			enabled: true,
+
+			resolver: stubResolver{
+				err: errors.New("no such host"),
+			},
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actual, err := LookupSRV(context.Background(), test.resolver, "html", "tcp", "mydomain.zone")
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
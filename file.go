@@ -1,12 +1,57 @@
 package afihtmlparser
 
 import (
+	"context"
+	"errors"
 	"io"
 	"time"
 
 	"github.com/stretchr/testify/mock"
 )
 
+// ReadMode defines how a Downloader decides where the content ends.
+type ReadMode string
+
+const (
+	// ReadModeFixed reads exactly content-length bytes.
+	ReadModeFixed ReadMode = "fixed"
+	// ReadModeEOF reads until the peer closes the connection, with content-length as the upper bound.
+	ReadModeEOF ReadMode = "eof"
+)
+
+// Framing defines how the content is delimited in the stream by the server itself, so that its
+// length doesn't have to be known up front.
+type Framing string
+
+const (
+	// FramingNone leaves the content boundaries to the ReadMode.
+	FramingNone Framing = ""
+	// FramingUint16 prefixes the content with a 2-byte big-endian length header.
+	FramingUint16 Framing = "uint16"
+	// FramingUint32 prefixes the content with a 4-byte big-endian length header.
+	FramingUint32 Framing = "uint32"
+	// FramingVarint prefixes the content with an unsigned varint length header.
+	FramingVarint Framing = "varint"
+	// FramingDelimiter terminates the content with a sentinel sequence.
+	FramingDelimiter Framing = "delimiter"
+)
+
+var (
+	ErrContentTooLarge = errors.New("content is larger than allowed content length")
+	ErrNetworkPolicy   = errors.New("network policy violation")
+)
+
+// NetworkPolicy restricts the remote hosts a Downloader may connect to.
+type NetworkPolicy interface {
+	// CheckHost is called with the hostname before it's resolved.
+	CheckHost(host string) (err error)
+	// CheckAddr is called at dial time with the resolved address.
+	CheckAddr(network, address string) (err error)
+}
+
+// DownloadCallback consumes the downloaded content. Unless the downloader is configured to buffer
+// the content, r reads straight from the underlying connection and is valid only until the
+// callback returns.
 type DownloadCallback func(r io.Reader) (err error)
 
 type Downloader interface {
@@ -39,6 +84,79 @@ type Parser interface {
 	Parse(r io.Reader) (nodes []string, err error)
 }
 
+// Result is the result of an expression: either the selected nodes or a scalar value.
+type Result struct {
+	Nodes []string
+	// Value is the float64, string or bool the expression evaluates to, e.g. count(//li). It's nil
+	// when the expression selects nodes.
+	Value interface{}
+}
+
+// Evaluator is a Parser supporting the expressions which evaluate to scalar values.
+type Evaluator interface {
+	EvaluateContext(ctx context.Context, r io.Reader) (result Result, err error)
+}
+
+// Document is a parsed document evaluating the expressions without parsing it again.
+type Document interface {
+	Evaluate(ctx context.Context, expression string) (result Result, err error)
+}
+
+// DocumentParser is a Parser parsing the document once for evaluating several expressions.
+type DocumentParser interface {
+	ParseDocument(ctx context.Context, r io.Reader) (doc Document, err error)
+}
+
+// SelectorType defines the language of the expression selecting the nodes.
+type SelectorType string
+
+const (
+	SelectorTypeXPath SelectorType = "xpath"
+	SelectorTypeCSS   SelectorType = "css"
+	// SelectorTypeJSONPath selects the nodes of JSON documents.
+	SelectorTypeJSONPath SelectorType = "jsonpath"
+)
+
+// DocumentType defines the markup language of the document.
+type DocumentType string
+
+const (
+	DocumentTypeHTML DocumentType = "html"
+	DocumentTypeXML  DocumentType = "xml"
+)
+
+// RenderMode defines how the selected nodes are returned.
+type RenderMode string
+
+const (
+	// RenderModeOuterHTML renders the nodes with their own tags, the default.
+	RenderModeOuterHTML RenderMode = "outer-html"
+	// RenderModeInnerHTML renders the children of the nodes.
+	RenderModeInnerHTML RenderMode = "inner-html"
+	// RenderModeText returns the text content of the nodes with the whitespace collapsed.
+	RenderModeText RenderMode = "text"
+	// RenderModeAttribute returns the values of the selected attributes, e.g. //a/@href.
+	RenderModeAttribute RenderMode = "attribute"
+)
+
+// ParserConfig is the parser part of the request.
+type ParserConfig struct {
+	// SelectorType is the language of the Expression, SelectorTypeXPath when empty.
+	SelectorType SelectorType
+	// Expression selects the nodes of the document.
+	Expression string
+	// Charset is the label of the document charset, detected by the parser when empty.
+	Charset string
+	// DocumentType is the markup language of the document, DocumentTypeHTML when empty.
+	DocumentType DocumentType
+	// Namespaces binds the prefixes used in the Expression to the namespace URIs of XML documents.
+	Namespaces map[string]string
+	// RenderMode defines how the selected nodes are returned, RenderModeOuterHTML when empty.
+	RenderMode RenderMode
+	// EscapeHTML keeps the rendered HTML markup escaped instead of unescaping its entities.
+	EscapeHTML bool
+}
+
 type MockParser struct {
 	mock.Mock
 }
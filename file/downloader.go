@@ -0,0 +1,136 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/morozovcookie/afihtmlparser/tcp"
+)
+
+var ErrOutsideRoot = errors.New("file is outside of the root directory")
+
+// Downloader reads the content from a file of the root directory with the same semantics as
+// tcp.Downloader reads it from a connection.
+type Downloader struct {
+	root     string
+	name     string
+	framer   tcp.Framer
+	buffered bool
+}
+
+type Option func(d *Downloader)
+
+// WithFramer sets the strategy extracting the content from the file, tcp.FixedLength by default.
+func WithFramer(framer tcp.Framer) Option {
+	return func(d *Downloader) {
+		d.framer = framer
+	}
+}
+
+// WithBuffering makes the downloader read the whole content before calling the callback.
+func WithBuffering(buffered bool) Option {
+	return func(d *Downloader) {
+		d.buffered = buffered
+	}
+}
+
+// NewDownloader creates the downloader of the named file. The name is resolved against root as if
+// root were the file system root, so neither "..", nor absolute names, nor symbolic links lead
+// outside of it.
+func NewDownloader(root, name string, opts ...Option) *Downloader {
+	d := &Downloader{
+		root:   root,
+		name:   name,
+		framer: tcp.FixedLength{},
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// Download reads the content framed by the downloader Framer from the file. The timeout applies to
+// the whole download including the time spent in the callback.
+func (d *Downloader) Download(contentLength int64, timeout time.Duration, callbackFn ahp.DownloadCallback) (err error) {
+	return d.DownloadContext(context.Background(), contentLength, timeout, callbackFn)
+}
+
+func (d *Downloader) DownloadContext(ctx context.Context, contentLength int64, timeout time.Duration,
+	callbackFn ahp.DownloadCallback) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	name, err := d.resolve()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	content, err := d.framer.Frame(ahp.NewContextReader(ctx, f), contentLength)
+	if err != nil {
+		return err
+	}
+
+	if d.buffered {
+		buf := &bytes.Buffer{}
+		if _, err = io.Copy(buf, content); err != nil {
+			return err
+		}
+
+		return callbackFn(bytes.NewReader(buf.Bytes()))
+	}
+
+	if err = callbackFn(content); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(ioutil.Discard, content)
+
+	return err
+}
+
+// resolve returns the name of the file inside the root directory with symbolic links evaluated.
+func (d *Downloader) resolve() (name string, err error) {
+	root, err := filepath.Abs(d.root)
+	if err != nil {
+		return "", err
+	}
+
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", err
+	}
+
+	name = filepath.Join(root, filepath.Clean(string(filepath.Separator)+filepath.FromSlash(d.name)))
+
+	if name, err = filepath.EvalSymlinks(name); err != nil {
+		return "", err
+	}
+
+	if !within(root, name) {
+		return "", ErrOutsideRoot
+	}
+
+	return name, nil
+}
+
+func within(root, name string) bool {
+	rel, err := filepath.Rel(root, name)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,163 @@
+package file
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/morozovcookie/afihtmlparser/tcp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloader_Download(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ahp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "root")
+
+	if err = os.MkdirAll(filepath.Join(root, "pages"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		filepath.Join(root, "pages", "index.html"): `<li>blabla</li>`,
+		filepath.Join(dir, "secret.html"):          `<li>secret</li>`,
+	}
+
+	for name, content := range files {
+		if err = ioutil.WriteFile(name, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = os.Symlink(filepath.Join(dir, "secret.html"), filepath.Join(root, "link.html")); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		path          string
+		framer        tcp.Framer
+		contentLength int64
+
+		wantErr     bool
+		expectedErr error
+		expected    string
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			path:          "/pages/index.html",
+			contentLength: 15,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "relative path",
+			enabled: true,
+
+			path:          "pages/index.html",
+			contentLength: 15,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "pass until eof",
+			enabled: true,
+
+			path:          "/pages/index.html",
+			framer:        tcp.UntilEOF{},
+			contentLength: 100,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "content is too large",
+			enabled: true,
+
+			path:          "/pages/index.html",
+			framer:        tcp.UntilEOF{},
+			contentLength: 10,
+
+			wantErr:     true,
+			expectedErr: ahp.ErrContentTooLarge,
+		},
+		{
+			name:    "short content",
+			enabled: true,
+
+			path:          "/pages/index.html",
+			contentLength: 20,
+
+			wantErr:     true,
+			expectedErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "dot-dot stays in the root",
+			enabled: true,
+
+			path:          "/../secret.html",
+			contentLength: 15,
+
+			wantErr:     true,
+			expectedErr: os.ErrNotExist,
+		},
+		{
+			name:    "symbolic link outside of the root",
+			enabled: true,
+
+			path:          "/link.html",
+			contentLength: 15,
+
+			wantErr:     true,
+			expectedErr: ErrOutsideRoot,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			var opts []Option
+			if test.framer != nil {
+				opts = append(opts, WithFramer(test.framer))
+			}
+
+			var actual string
+
+			err := NewDownloader(root, test.path, opts...).
+				Download(test.contentLength, time.Second, func(r io.Reader) (err error) {
+					b, err := ioutil.ReadAll(r)
+					actual = string(b)
+
+					return err
+				})
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if test.wantErr {
+				assert.True(t, errors.Is(err, test.expectedErr), err)
+
+				return
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package file
+
+import (
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/morozovcookie/afihtmlparser/tcp"
+)
+
+const Scheme = "file"
+
+// NewFactory returns the factory of the downloaders reading files of the root directory. Unlike the
+// network transports, the file transport is not registered on import: reading local files must be
+// enabled explicitly with ahp.RegisterDownloader(file.Scheme, file.NewFactory(root)).
+func NewFactory(root string) ahp.DownloaderFactory {
+	return func(cfg ahp.DownloaderConfig) (ahp.Downloader, error) {
+		opts := []Option{
+			WithBuffering(cfg.Buffered),
+		}
+
+		if cfg.ReadMode == ahp.ReadModeEOF {
+			opts = append(opts, WithFramer(tcp.UntilEOF{}))
+		}
+
+		if cfg.Framing != ahp.FramingNone {
+			framer, err := tcp.NewFramer(cfg.Framing, cfg.Delimiter)
+			if err != nil {
+				return nil, err
+			}
+
+			opts = append(opts, WithFramer(framer))
+		}
+
+		return NewDownloader(root, cfg.Address, opts...), nil
+	}
+}
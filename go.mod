@@ -3,7 +3,13 @@ module github.com/morozovcookie/afihtmlparser
 go 1.15
 
 require (
+	github.com/PaesslerAG/gval v1.0.0
+	github.com/PaesslerAG/jsonpath v0.1.1
+	github.com/andybalholm/cascadia v1.2.0
 	github.com/antchfx/htmlquery v1.2.3
+	github.com/antchfx/xmlquery v1.3.5
+	github.com/antchfx/xpath v1.2.4
 	github.com/stretchr/testify v1.6.1
-	golang.org/x/net v0.0.0-20200421231249-e086a090c8fd
+	golang.org/x/net v0.0.0-20200813134508-3edf25e44fcc
+	golang.org/x/text v0.3.0
 )
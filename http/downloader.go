@@ -0,0 +1,124 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+)
+
+var ErrUnexpectedStatusCode = errors.New("unexpected status code")
+
+// Downloader fetches content with a HTTP GET request. Redirects are followed
+// by the underlying client, chunked bodies are decoded transparently and the
+// content-length passed to Download is treated as the maximum body size
+// regardless of the read mode.
+type Downloader struct {
+	address   string
+	client    *http.Client
+	transport *http.Transport
+	dialer    *net.Dialer
+	policy    ahp.NetworkPolicy
+}
+
+type Option func(d *Downloader)
+
+// WithTLS sets the client configuration for https requests.
+func WithTLS(cfg *tls.Config) Option {
+	return func(d *Downloader) {
+		d.transport.TLSClientConfig = cfg
+	}
+}
+
+// WithPolicy makes the downloader refuse connections violating the network policy, including the
+// ones made for redirects. Proxies from the environment are not used then, as the policy can't be
+// enforced on the connections they make.
+func WithPolicy(policy ahp.NetworkPolicy) Option {
+	return func(d *Downloader) {
+		d.policy = policy
+		d.transport.Proxy = nil
+		d.dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			return policy.CheckAddr(network, address)
+		}
+	}
+}
+
+func NewDownloader(address string, timeout time.Duration, opts ...Option) *Downloader {
+	d := &Downloader{
+		address: address,
+		dialer:  &net.Dialer{Timeout: timeout},
+	}
+
+	d.transport = &http.Transport{
+		Proxy:       http.ProxyFromEnvironment,
+		DialContext: d.dialContext,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	d.client = &http.Client{
+		Transport: d.transport,
+	}
+
+	return d
+}
+
+func (d *Downloader) Download(contentLength int64, timeout time.Duration, callbackFn ahp.DownloadCallback) (err error) {
+	return d.DownloadContext(context.Background(), contentLength, timeout, callbackFn)
+}
+
+// DownloadContext is Download which cancels the request once the context is done.
+func (d *Downloader) DownloadContext(ctx context.Context, contentLength int64, timeout time.Duration,
+	callbackFn ahp.DownloadCallback) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.address, nil)
+	if err != nil {
+		return err
+	}
+
+	client := *d.client
+	client.Timeout = timeout
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %s", ErrUnexpectedStatusCode, resp.Status)
+	}
+
+	if resp.ContentLength > contentLength {
+		return ahp.ErrContentTooLarge
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err = io.Copy(buf, ahp.NewLimitedReader(resp.Body, contentLength, ahp.ErrContentTooLarge)); err != nil {
+		return err
+	}
+
+	return callbackFn(buf)
+}
+
+func (d *Downloader) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.policy != nil {
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			if err = d.policy.CheckHost(host); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return d.dialer.DialContext(ctx, network, address)
+}
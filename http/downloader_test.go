@@ -0,0 +1,271 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/morozovcookie/afihtmlparser/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloader_Download(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		handler http.Handler
+
+		contentLength int64
+		timeout       time.Duration
+		callback      func(r io.Reader) (err error)
+
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`<li>blabla</li>`))
+			}),
+
+			contentLength: 15,
+			timeout:       time.Second,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "pass with chunked body",
+			enabled: true,
+
+			handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`<li>`))
+				w.(http.Flusher).Flush()
+				_, _ = w.Write([]byte(`blabla</li>`))
+			}),
+
+			contentLength: 100,
+			timeout:       time.Second,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "pass with redirect",
+			enabled: true,
+
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/" {
+					http.Redirect(w, r, "/page", http.StatusFound)
+
+					return
+				}
+
+				_, _ = w.Write([]byte(`<li>blabla</li>`))
+			}),
+
+			contentLength: 15,
+			timeout:       time.Second,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "pass with maximum content length",
+			enabled: true,
+
+			handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`<li>blabla</li>`))
+			}),
+
+			contentLength: math.MaxInt64,
+			timeout:       time.Second,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "unexpected status code",
+			enabled: true,
+
+			handler: http.NotFoundHandler(),
+
+			contentLength: 100,
+			timeout:       time.Second,
+
+			wantErr: true,
+		},
+		{
+			name:    "content-length header is too large",
+			enabled: true,
+
+			handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`<li>blabla</li>`))
+			}),
+
+			contentLength: 10,
+			timeout:       time.Second,
+
+			wantErr: true,
+		},
+		{
+			name:    "chunked body is too large",
+			enabled: true,
+
+			handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`<li>`))
+				w.(http.Flusher).Flush()
+				_, _ = w.Write([]byte(`blabla</li>`))
+			}),
+
+			contentLength: 10,
+			timeout:       time.Second,
+
+			wantErr: true,
+		},
+		{
+			name:    "callback error",
+			enabled: true,
+
+			handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`<li>blabla</li>`))
+			}),
+
+			contentLength: 15,
+			timeout:       time.Second,
+			callback: func(_ io.Reader) (err error) {
+				return errors.New("some error")
+			},
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			srv := httptest.NewServer(test.handler)
+			defer srv.Close()
+
+			var actual string
+
+			callback := test.callback
+			if callback == nil {
+				callback = func(r io.Reader) (err error) {
+					b, err := ioutil.ReadAll(r)
+					actual = string(b)
+
+					return err
+				}
+			}
+
+			err := NewDownloader(srv.URL, time.Second).Download(test.contentLength, test.timeout, callback)
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestDownloader_DownloadWithPolicy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`<li>blabla</li>`))
+	}))
+	defer target.Close()
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		cfg      func(srv *httptest.Server) policy.Config
+		redirect bool
+
+		wantErr bool
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			cfg: func(_ *httptest.Server) policy.Config {
+				return policy.Config{
+					AllowCIDRs: []string{"127.0.0.0/8"},
+				}
+			},
+		},
+		{
+			name:    "denied address",
+			enabled: true,
+
+			cfg: func(_ *httptest.Server) policy.Config {
+				return policy.Config{
+					DenyPrivate: true,
+				}
+			},
+
+			wantErr: true,
+		},
+		{
+			name:    "redirect to denied port",
+			enabled: true,
+
+			cfg: func(srv *httptest.Server) policy.Config {
+				return policy.Config{
+					Ports: []string{srv.URL[strings.LastIndexByte(srv.URL, ':')+1:]},
+				}
+			},
+			redirect: true,
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.redirect {
+					http.Redirect(w, r, target.URL, http.StatusFound)
+
+					return
+				}
+
+				_, _ = w.Write([]byte(`<li>blabla</li>`))
+			}))
+			defer srv.Close()
+
+			p, err := policy.New(test.cfg(srv))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = NewDownloader(srv.URL, time.Second, WithPolicy(p)).
+				Download(15, time.Second, func(r io.Reader) (err error) {
+					_, err = io.Copy(ioutil.Discard, r)
+
+					return err
+				})
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+			}
+
+			if test.wantErr {
+				assert.True(t, errors.Is(err, ahp.ErrNetworkPolicy))
+			}
+		})
+	}
+}
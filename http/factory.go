@@ -0,0 +1,30 @@
+package http
+
+import (
+	ahp "github.com/morozovcookie/afihtmlparser"
+)
+
+const (
+	Scheme       = "http"
+	SecureScheme = "https"
+)
+
+func init() {
+	ahp.RegisterDownloader(Scheme, Factory)
+	ahp.RegisterDownloader(SecureScheme, Factory)
+}
+
+// Factory builds a Downloader from the configuration resolved by ahp.DownloaderRegistry.
+func Factory(cfg ahp.DownloaderConfig) (ahp.Downloader, error) {
+	var opts []Option
+
+	if cfg.TLS != nil {
+		opts = append(opts, WithTLS(cfg.TLS))
+	}
+
+	if cfg.Policy != nil {
+		opts = append(opts, WithPolicy(cfg.Policy))
+	}
+
+	return NewDownloader(cfg.Scheme+"://"+cfg.Address, cfg.DialTimeout, opts...), nil
+}
@@ -0,0 +1,267 @@
+package jsonpath
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/PaesslerAG/gval"
+	paessler "github.com/PaesslerAG/jsonpath"
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/morozovcookie/afihtmlparser/charset"
+)
+
+var (
+	ErrSyntax       = errors.New("jsonpath syntax error")
+	ErrTrailingData = errors.New("unexpected data after json document")
+)
+
+// language is JSONPath with the operators of gval in the filters, e.g.
+// "$.store..book[?(@.price < 10 && @.isbn)].title".
+var language = gval.Full(paessler.Language())
+
+// Parser selects the nodes of JSON documents matching a JSONPath expression and returns each of
+// them serialized as JSON, keeping the numbers as they are written. The members of an object
+// matched by a wildcard come in no particular order.
+type Parser struct {
+	expression string
+	label      string
+
+	path *path
+
+	charset string
+}
+
+type Option func(p *Parser)
+
+// WithCharset sets the charset of the documents. By default UTF-8 is assumed unless the document
+// starts with a byte order mark.
+func WithCharset(label string) Option {
+	return func(p *Parser) {
+		p.label = label
+	}
+}
+
+func NewParser(expression string, opts ...Option) *Parser {
+	p := &Parser{
+		expression: expression,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Charset returns the canonical name of the charset the last parsed document was transcoded from.
+func (p *Parser) Charset() string {
+	return p.charset
+}
+
+func (p *Parser) Parse(r io.Reader) ([]string, error) {
+	return p.ParseContext(context.Background(), r)
+}
+
+// ParseContext is Parse which stops reading the document and serializing the nodes once the
+// context is done.
+func (p *Parser) ParseContext(ctx context.Context, r io.Reader) ([]string, error) {
+	if p.path == nil {
+		path, err := compile(p.expression)
+		if err != nil {
+			return nil, err
+		}
+
+		p.path = path
+	}
+
+	doc, err := p.parseDocument(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := doc.evaluate(ctx, p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Nodes, nil
+}
+
+// ParseDocument decodes the document once for evaluating several expressions.
+func (p *Parser) ParseDocument(ctx context.Context, r io.Reader) (ahp.Document, error) {
+	return p.parseDocument(ctx, r)
+}
+
+func (p *Parser) parseDocument(ctx context.Context, r io.Reader) (*jsonDocument, error) {
+	r, name, err := charset.NewTextReader(ahp.NewContextReader(ctx, r), p.label)
+	if err != nil {
+		return nil, err
+	}
+
+	p.charset = name
+
+	var (
+		dec = json.NewDecoder(r)
+		v   interface{}
+	)
+
+	dec.UseNumber()
+
+	if err = dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	if _, err = dec.Token(); err == nil {
+		return nil, ErrTrailingData
+	} else if err != io.EOF {
+		return nil, err
+	}
+
+	doc := &jsonDocument{numbers: make(map[*number]json.Number)}
+	doc.value = doc.wrap(v)
+
+	return doc, nil
+}
+
+// path is a compiled JSONPath expression.
+type path struct {
+	eval gval.Evaluable
+	// definite is set for the paths selecting a single value, which the library returns as is
+	// rather than in a list of matches.
+	definite bool
+}
+
+func compile(expression string) (*path, error) {
+	eval, err := language.NewEvaluable(expression)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+
+	// An ambiguous path, i.e. with wildcards, descendants, unions, slices or filters, lists its
+	// matches even in an empty document, whereas a definite path fails to select from it.
+	matches, err := eval(context.Background(), nil)
+	_, ambiguous := matches.([]interface{})
+
+	return &path{eval: eval, definite: err != nil || !ambiguous}, nil
+}
+
+// evaluate returns the values matched in v. A definite path matching nothing fails, e.g. with an
+// unknown key, which stands for no matches as in the ambiguous paths.
+func (p *path) evaluate(ctx context.Context, v interface{}) ([]interface{}, error) {
+	matches, err := p.eval(ctx, v)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	if !p.definite {
+		vv, _ := matches.([]interface{})
+
+		return vv, nil
+	}
+
+	if err != nil {
+		return []interface{}{}, nil
+	}
+
+	return []interface{}{matches}, nil
+}
+
+// number is the value of a JSON number in the document. gval compares the pointers to float64
+// values as numbers, whereas json.Number is a string to it, and the pointer keys the text of the
+// number to serialize it as it's written.
+type number float64
+
+type jsonDocument struct {
+	value   interface{}
+	numbers map[*number]json.Number
+}
+
+// wrap replaces the json.Number values of v with the numbers the filters compare.
+func (d *jsonDocument) wrap(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		n := number(f)
+		d.numbers[&n] = v
+
+		return &n
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = d.wrap(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = d.wrap(e)
+		}
+	}
+
+	return v
+}
+
+// unwrap returns the copy of v with the numbers replaced by their text.
+func (d *jsonDocument) unwrap(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *number:
+		return d.numbers[v]
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = d.unwrap(e)
+		}
+
+		return m
+	case []interface{}:
+		vv := make([]interface{}, len(v))
+		for i, e := range v {
+			vv[i] = d.unwrap(e)
+		}
+
+		return vv
+	}
+
+	return v
+}
+
+func (d *jsonDocument) Evaluate(ctx context.Context, expression string) (result ahp.Result, err error) {
+	path, err := compile(expression)
+	if err != nil {
+		return result, err
+	}
+
+	return d.evaluate(ctx, path)
+}
+
+func (d *jsonDocument) evaluate(ctx context.Context, path *path) (result ahp.Result, err error) {
+	nn, err := path.evaluate(ctx, d.value)
+	if err != nil {
+		return result, err
+	}
+
+	var (
+		nbuf = &bytes.Buffer{}
+		enc  = json.NewEncoder(nbuf)
+	)
+
+	enc.SetEscapeHTML(false)
+
+	result.Nodes = make([]string, 0, len(nn))
+
+	for _, n := range nn {
+		if err = ctx.Err(); err != nil {
+			return result, err
+		}
+
+		if err = enc.Encode(d.unwrap(n)); err != nil {
+			return result, err
+		}
+
+		result.Nodes = append(result.Nodes, string(bytes.TrimSuffix(nbuf.Bytes(), []byte("\n"))))
+		nbuf.Reset()
+	}
+
+	return result, nil
+}
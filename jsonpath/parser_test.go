@@ -0,0 +1,199 @@
+package jsonpath
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParser_Parse(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		document   string
+		expression string
+
+		wantErr bool
+
+		expectedNodes []string
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			document:   `{"items": [{"name": "<li>blabla</li>", "price": 1.50, "tags": ["a", "b"]}, {"name": "x"}]}`,
+			expression: `$.items[?(@.price)]`,
+
+			expectedNodes: []string{
+				`{"name":"<li>blabla</li>","price":1.50,"tags":["a","b"]}`,
+			},
+		},
+		{
+			name:    "scalars",
+			enabled: true,
+
+			document:   `{"items": [{"name": "a", "count": 10}, {"name": null}]}`,
+			expression: `$.items[*].name`,
+
+			expectedNodes: []string{
+				`"a"`,
+				`null`,
+			},
+		},
+		{
+			name:    "large integers",
+			enabled: true,
+
+			document:   `{"items": [{"id": 9007199254740993}, {"id": 12345678901234567890, "price": 1e2}]}`,
+			expression: `$.items[?(@.id > 9007199254740992)]`,
+
+			expectedNodes: []string{
+				`{"id":12345678901234567890,"price":1e2}`,
+			},
+		},
+		{
+			name:    "numbers in filters",
+			enabled: true,
+
+			document:   `{"items": [{"id": 9007199254740993, "price": 0}, {"id": 2, "price": 1.50}]}`,
+			expression: `$.items[?(@.price == 1.5 || @.price)].id`,
+
+			expectedNodes: []string{
+				`2`,
+			},
+		},
+		{
+			name:    "definite path to a number",
+			enabled: true,
+
+			document:   `{"items": [{"id": 9007199254740993}]}`,
+			expression: `$.items[0].id`,
+
+			expectedNodes: []string{
+				`9007199254740993`,
+			},
+		},
+		{
+			name:    "definite path to an array",
+			enabled: true,
+
+			document:   `{"items": [{"name": "a"}, {"name": "b"}]}`,
+			expression: `$.items`,
+
+			expectedNodes: []string{
+				`[{"name":"a"},{"name":"b"}]`,
+			},
+		},
+		{
+			name:    "filter with comparison to root",
+			enabled: true,
+
+			document:   `{"max": 10, "items": [{"price": 8.95}, {"price": 12.99}, {"price": 10}]}`,
+			expression: `$.items[?(@.price <= $.max)].price`,
+
+			expectedNodes: []string{
+				`8.95`,
+				`10`,
+			},
+		},
+		{
+			name:    "descendants",
+			enabled: true,
+
+			document:   `[{"author": "a", "books": [{"author": "b"}]}]`,
+			expression: `$..books..author`,
+
+			expectedNodes: []string{
+				`"b"`,
+			},
+		},
+		{
+			name:    "unknown key",
+			enabled: true,
+
+			document:   `{"items": []}`,
+			expression: `$.store.book`,
+
+			expectedNodes: []string{},
+		},
+		{
+			name:    "empty nodes list",
+			enabled: true,
+
+			document:   `[]`,
+			expression: `$[0]`,
+
+			expectedNodes: []string{},
+		},
+		{
+			name:    "meta tag in string",
+			enabled: true,
+
+			document:   `{"html": "<meta charset=windows-1251>", "title": "Привет"}`,
+			expression: `$.title`,
+
+			expectedNodes: []string{
+				`"Привет"`,
+			},
+		},
+		{
+			name:    "invalid document",
+			enabled: true,
+
+			document:   `{"items": [}`,
+			expression: `$.items`,
+
+			wantErr: true,
+		},
+		{
+			name:    "trailing data",
+			enabled: true,
+
+			document:   `{"items": []} {"items": []}`,
+			expression: `$.items`,
+
+			wantErr: true,
+		},
+		{
+			name:    "invalid expression",
+			enabled: true,
+
+			document:   `{"items": []}`,
+			expression: `$.items[`,
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actualNodes, err := NewParser(test.expression).Parse(bytes.NewBufferString(test.document))
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			assert.Equal(t, test.expectedNodes, actualNodes)
+		})
+	}
+}
+
+func TestParser_ParseContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewParser(`$.items`).ParseContext(ctx, bytes.NewBufferString(`{"items": []}`))
+
+	assert.Equal(t, context.Canceled, err)
+}
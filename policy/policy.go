@@ -0,0 +1,307 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+)
+
+// PrivateCIDRs are the loopback, link-local, private and otherwise non-public ranges denied by
+// Config.DenyPrivate. NAT64 and 6to4 addresses embed IPv4 ones, so they are denied as a whole.
+var PrivateCIDRs = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"2002::/16",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+}
+
+// Config is the serialized form of the Policy.
+type Config struct {
+	AllowCIDRs  []string `json:"allow-cidrs"`
+	DenyCIDRs   []string `json:"deny-cidrs"`
+	AllowHosts  []string `json:"allow-hosts"`
+	DenyHosts   []string `json:"deny-hosts"`
+	Ports       []string `json:"ports"`
+	DenyPrivate bool     `json:"deny-private"`
+}
+
+type PortRange struct {
+	From int
+	To   int
+}
+
+func (r PortRange) Contains(port int) bool {
+	return port >= r.From && port <= r.To
+}
+
+// Policy restricts the hosts downloaders may connect to. Denials take precedence over allowances,
+// and when an allow list is not empty, everything it doesn't match is denied. Host patterns are
+// matched against hostnames before resolution, CIDRs and ports against every resolved address.
+type Policy struct {
+	allowCIDRs []*net.IPNet
+	denyCIDRs  []*net.IPNet
+	allowHosts []string
+	denyHosts  []string
+	ports      []PortRange
+}
+
+func New(cfg Config) (p *Policy, err error) {
+	p = &Policy{
+		allowHosts: lower(cfg.AllowHosts),
+		denyHosts:  lower(cfg.DenyHosts),
+	}
+
+	denyCIDRs := cfg.DenyCIDRs
+	if cfg.DenyPrivate {
+		denyCIDRs = append(append([]string(nil), denyCIDRs...), PrivateCIDRs...)
+	}
+
+	if p.allowCIDRs, err = parseCIDRs(cfg.AllowCIDRs); err != nil {
+		return nil, err
+	}
+
+	if p.denyCIDRs, err = parseCIDRs(denyCIDRs); err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range append(append([]string(nil), p.allowHosts...), p.denyHosts...) {
+		if _, err = path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid host pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, s := range cfg.Ports {
+		r, err := parsePortRange(s)
+		if err != nil {
+			return nil, err
+		}
+
+		p.ports = append(p.ports, r)
+	}
+
+	return p, nil
+}
+
+// CheckHost checks the hostname against the host patterns. IP literals are left to CheckAddr.
+func (p *Policy) CheckHost(host string) (err error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+
+	if matchHost(p.denyHosts, host) {
+		return fmt.Errorf("%w: host %s is denied", ahp.ErrNetworkPolicy, host)
+	}
+
+	if len(p.allowHosts) != 0 && !matchHost(p.allowHosts, host) {
+		return fmt.Errorf("%w: host %s is not allowed", ahp.ErrNetworkPolicy, host)
+	}
+
+	return nil
+}
+
+// CheckAddr checks the resolved "ip:port" address against the CIDRs and port ranges. Non-IP
+// networks, such as Unix sockets, are denied as the policy can't restrict them.
+func (p *Policy) CheckAddr(network, address string) (err error) {
+	if !strings.HasPrefix(network, "tcp") && !strings.HasPrefix(network, "udp") {
+		return fmt.Errorf("%w: network %s is not allowed", ahp.ErrNetworkPolicy, network)
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ahp.ErrNetworkPolicy, err)
+	}
+
+	if i := strings.LastIndexByte(host, '%'); i >= 0 {
+		host = host[:i]
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: address %s is not resolved", ahp.ErrNetworkPolicy, address)
+	}
+
+	if matchCIDR(p.denyCIDRs, ip) {
+		return fmt.Errorf("%w: address %s is denied", ahp.ErrNetworkPolicy, ip)
+	}
+
+	if len(p.allowCIDRs) != 0 && !matchCIDR(p.allowCIDRs, ip) {
+		return fmt.Errorf("%w: address %s is not allowed", ahp.ErrNetworkPolicy, ip)
+	}
+
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ahp.ErrNetworkPolicy, err)
+	}
+
+	if len(p.ports) != 0 && !matchPort(p.ports, portNum) {
+		return fmt.Errorf("%w: port %d is not allowed", ahp.ErrNetworkPolicy, portNum)
+	}
+
+	return nil
+}
+
+// Load reads the JSON encoded Config.
+func Load(r io.Reader) (p *Policy, err error) {
+	var cfg Config
+
+	if err = json.NewDecoder(r).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid network policy: %w", err)
+	}
+
+	return New(cfg)
+}
+
+func LoadFile(name string) (p *Policy, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return Load(f)
+}
+
+const (
+	EnvFile        = "AHP_POLICY_FILE"
+	EnvAllowCIDRs  = "AHP_POLICY_ALLOW_CIDRS"
+	EnvDenyCIDRs   = "AHP_POLICY_DENY_CIDRS"
+	EnvAllowHosts  = "AHP_POLICY_ALLOW_HOSTS"
+	EnvDenyHosts   = "AHP_POLICY_DENY_HOSTS"
+	EnvPorts       = "AHP_POLICY_PORTS"
+	EnvDenyPrivate = "AHP_POLICY_DENY_PRIVATE"
+)
+
+// FromEnv loads the policy from the file named by AHP_POLICY_FILE or, when it's not set, builds it
+// from the comma separated lists in the other AHP_POLICY_* variables. It returns nil when none of
+// them is set.
+func FromEnv() (p *Policy, err error) {
+	if name := os.Getenv(EnvFile); name != "" {
+		return LoadFile(name)
+	}
+
+	var (
+		cfg = Config{
+			AllowCIDRs: splitEnv(EnvAllowCIDRs),
+			DenyCIDRs:  splitEnv(EnvDenyCIDRs),
+			AllowHosts: splitEnv(EnvAllowHosts),
+			DenyHosts:  splitEnv(EnvDenyHosts),
+			Ports:      splitEnv(EnvPorts),
+		}
+
+		denyPrivate = os.Getenv(EnvDenyPrivate)
+	)
+
+	if denyPrivate != "" {
+		if cfg.DenyPrivate, err = strconv.ParseBool(denyPrivate); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvDenyPrivate, err)
+		}
+	}
+
+	if len(cfg.AllowCIDRs)+len(cfg.DenyCIDRs)+len(cfg.AllowHosts)+len(cfg.DenyHosts)+len(cfg.Ports) == 0 &&
+		!cfg.DenyPrivate {
+		return nil, nil
+	}
+
+	return New(cfg)
+}
+
+func splitEnv(key string) (values []string) {
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+func parseCIDRs(ss []string) (nets []*net.IPNet, err error) {
+	for _, s := range ss {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+func parsePortRange(s string) (r PortRange, err error) {
+	from, to := s, s
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		from, to = s[:i], s[i+1:]
+	}
+
+	if r.From, err = strconv.Atoi(from); err == nil {
+		r.To, err = strconv.Atoi(to)
+	}
+
+	if err != nil || r.From < 1 || r.To > 65535 || r.From > r.To {
+		return r, fmt.Errorf("invalid port range %q", s)
+	}
+
+	return r, nil
+}
+
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, host); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchCIDR(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchPort(ranges []PortRange, port int) bool {
+	for _, r := range ranges {
+		if r.Contains(port) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lower(ss []string) (out []string) {
+	for _, s := range ss {
+		out = append(out, strings.ToLower(s))
+	}
+
+	return out
+}
@@ -0,0 +1,389 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_CheckHost(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		cfg  Config
+		host string
+
+		wantErr bool
+	}{
+		{
+			name:    "pass without restrictions",
+			enabled: true,
+
+			host: "example.com",
+		},
+		{
+			name:    "pass with allowed host",
+			enabled: true,
+
+			cfg: Config{
+				AllowHosts: []string{"*.example.com"},
+			},
+			host: "WWW.Example.com.",
+		},
+		{
+			name:    "host is not allowed",
+			enabled: true,
+
+			cfg: Config{
+				AllowHosts: []string{"*.example.com"},
+			},
+			host: "example.org",
+
+			wantErr: true,
+		},
+		{
+			name:    "denied host",
+			enabled: true,
+
+			cfg: Config{
+				AllowHosts: []string{"*.example.com"},
+				DenyHosts:  []string{"internal.example.com"},
+			},
+			host: "internal.example.com",
+
+			wantErr: true,
+		},
+		{
+			name:    "ip literal is left to address check",
+			enabled: true,
+
+			cfg: Config{
+				AllowHosts: []string{"*.example.com"},
+			},
+			host: "127.0.0.1",
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			p, err := New(test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = p.CheckHost(test.host)
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+			}
+
+			if test.wantErr {
+				assert.True(t, errors.Is(err, ahp.ErrNetworkPolicy))
+			}
+		})
+	}
+}
+
+func TestPolicy_CheckAddr(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		cfg     Config
+		network string
+		address string
+
+		wantErr bool
+	}{
+		{
+			name:    "pass without restrictions",
+			enabled: true,
+
+			network: "tcp4",
+			address: "127.0.0.1:80",
+		},
+		{
+			name:    "private address is denied",
+			enabled: true,
+
+			cfg: Config{
+				DenyPrivate: true,
+			},
+			network: "tcp4",
+			address: "10.1.2.3:80",
+
+			wantErr: true,
+		},
+		{
+			name:    "link-local ipv6 address with zone is denied",
+			enabled: true,
+
+			cfg: Config{
+				DenyPrivate: true,
+			},
+			network: "tcp6",
+			address: "[fe80::1%eth0]:80",
+
+			wantErr: true,
+		},
+		{
+			name:    "benchmarking address is denied",
+			enabled: true,
+
+			cfg: Config{
+				DenyPrivate: true,
+			},
+			network: "tcp4",
+			address: "198.18.0.1:80",
+
+			wantErr: true,
+		},
+		{
+			name:    "reserved address is denied",
+			enabled: true,
+
+			cfg: Config{
+				DenyPrivate: true,
+			},
+			network: "tcp4",
+			address: "240.0.0.1:80",
+
+			wantErr: true,
+		},
+		{
+			name:    "nat64 address embedding private one is denied",
+			enabled: true,
+
+			cfg: Config{
+				DenyPrivate: true,
+			},
+			network: "tcp6",
+			address: "[64:ff9b::a01:203]:80",
+
+			wantErr: true,
+		},
+		{
+			name:    "6to4 address embedding private one is denied",
+			enabled: true,
+
+			cfg: Config{
+				DenyPrivate: true,
+			},
+			network: "tcp6",
+			address: "[2002:a01:203::1]:80",
+
+			wantErr: true,
+		},
+		{
+			name:    "public address passes private deny list",
+			enabled: true,
+
+			cfg: Config{
+				DenyPrivate: true,
+			},
+			network: "tcp4",
+			address: "93.184.216.34:443",
+		},
+		{
+			name:    "deny takes precedence over allow",
+			enabled: true,
+
+			cfg: Config{
+				AllowCIDRs: []string{"10.0.0.0/8"},
+				DenyCIDRs:  []string{"10.0.0.0/16"},
+			},
+			network: "tcp4",
+			address: "10.0.1.1:80",
+
+			wantErr: true,
+		},
+		{
+			name:    "address is not allowed",
+			enabled: true,
+
+			cfg: Config{
+				AllowCIDRs: []string{"10.0.0.0/8"},
+			},
+			network: "tcp4",
+			address: "192.168.0.1:80",
+
+			wantErr: true,
+		},
+		{
+			name:    "pass with allowed port range",
+			enabled: true,
+
+			cfg: Config{
+				Ports: []string{"80", "8000-8999"},
+			},
+			network: "tcp4",
+			address: "127.0.0.1:8080",
+		},
+		{
+			name:    "port is not allowed",
+			enabled: true,
+
+			cfg: Config{
+				Ports: []string{"80", "8000-8999"},
+			},
+			network: "tcp4",
+			address: "127.0.0.1:9000",
+
+			wantErr: true,
+		},
+		{
+			name:    "unix socket is denied",
+			enabled: true,
+
+			cfg: Config{
+				AllowCIDRs: []string{"10.0.0.0/8"},
+			},
+			network: "unix",
+			address: "/var/run/app.sock",
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			p, err := New(test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = p.CheckAddr(test.network, test.address)
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+			}
+
+			if test.wantErr {
+				assert.True(t, errors.Is(err, ahp.ErrNetworkPolicy))
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		cfg Config
+
+		wantErr bool
+	}{
+		{
+			name:    "invalid cidr",
+			enabled: true,
+
+			cfg: Config{
+				DenyCIDRs: []string{"10.0.0.0/33"},
+			},
+
+			wantErr: true,
+		},
+		{
+			name:    "invalid host pattern",
+			enabled: true,
+
+			cfg: Config{
+				DenyHosts: []string{"[a-"},
+			},
+
+			wantErr: true,
+		},
+		{
+			name:    "invalid port range",
+			enabled: true,
+
+			cfg: Config{
+				Ports: []string{"9000-8000"},
+			},
+
+			wantErr: true,
+		},
+		{
+			name:    "port out of range",
+			enabled: true,
+
+			cfg: Config{
+				Ports: []string{"65536"},
+			},
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			if _, err := New(test.cfg); (err != nil) != test.wantErr {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	p, err := Load(strings.NewReader(`{"deny-private":true,"ports":["443"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Error(t, p.CheckAddr("tcp", "127.0.0.1:443"))
+	assert.Error(t, p.CheckAddr("tcp", "93.184.216.34:80"))
+	assert.NoError(t, p.CheckAddr("tcp", "93.184.216.34:443"))
+
+	_, err = Load(strings.NewReader(`{"ports":443}`))
+	assert.Error(t, err)
+}
+
+func TestFromEnv(t *testing.T) {
+	for _, key := range []string{EnvFile, EnvAllowCIDRs, EnvDenyCIDRs, EnvAllowHosts, EnvDenyHosts, EnvPorts,
+		EnvDenyPrivate} {
+		if v, ok := os.LookupEnv(key); ok {
+			defer os.Setenv(key, v)
+		}
+
+		_ = os.Unsetenv(key)
+	}
+
+	p, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, p)
+
+	_ = os.Setenv(EnvDenyHosts, "localhost, *.internal")
+	defer os.Unsetenv(EnvDenyHosts)
+
+	if p, err = FromEnv(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Error(t, p.CheckHost("db.internal"))
+	assert.NoError(t, p.CheckHost("example.com"))
+
+	_ = os.Setenv(EnvDenyPrivate, "maybe")
+	defer os.Unsetenv(EnvDenyPrivate)
+
+	_, err = FromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,34 @@
+package afihtmlparser
+
+import (
+	"io"
+	"math"
+)
+
+// NewLimitedReader returns a reader which fails with err once more than n bytes are read from r.
+// Unlike io.LimitReader, it tells the content which exactly fits the limit from the exceeding one.
+func NewLimitedReader(r io.Reader, n int64, err error) io.Reader {
+	return &limitedReader{r: r, n: n, err: err}
+}
+
+type limitedReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (lr *limitedReader) Read(p []byte) (n int, err error) {
+	// The content is read up to a byte over the limit to tell it exceeds the limit.
+	if lr.n < math.MaxInt64 && int64(len(p)) > lr.n+1 {
+		p = p[:lr.n+1]
+	}
+
+	n, err = lr.r.Read(p)
+	if int64(n) > lr.n {
+		return int(lr.n), lr.err
+	}
+
+	lr.n -= int64(n)
+
+	return n, err
+}
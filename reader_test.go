@@ -0,0 +1,82 @@
+package afihtmlparser
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLimitedReader(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		content string
+		limit   int64
+
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:    "content shorter than limit",
+			enabled: true,
+
+			content: `<li>blabla</li>`,
+			limit:   100,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "content fits limit",
+			enabled: true,
+
+			content: `<li>blabla</li>`,
+			limit:   15,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "maximum limit",
+			enabled: true,
+
+			content: `<li>blabla</li>`,
+			limit:   math.MaxInt64,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "content exceeds limit",
+			enabled: true,
+
+			content: `<li>blabla</li>`,
+			limit:   14,
+
+			wantErr:  true,
+			expected: `<li>blabla</li`,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actual, err := ioutil.ReadAll(NewLimitedReader(bytes.NewBufferString(test.content), test.limit,
+				ErrContentTooLarge))
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if test.wantErr {
+				assert.Equal(t, ErrContentTooLarge, err)
+			}
+
+			assert.Equal(t, test.expected, string(actual))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package afihtmlparser
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultScheme is the scheme assumed for addresses without a "scheme://" prefix.
+const DefaultScheme = "tcp"
+
+const schemeSeparator = "://"
+
+var ErrUnknownScheme = errors.New("unknown address scheme")
+
+// DownloaderConfig describes the remote side a Downloader should fetch content from.
+type DownloaderConfig struct {
+	// Scheme is the transport name the address was registered under, e.g. "tcp" or "http".
+	Scheme string
+	// Address is the address without the scheme prefix.
+	Address     string
+	DialTimeout time.Duration
+	ReadMode    ReadMode
+	Framing     Framing
+	// Delimiter is the content terminator for FramingDelimiter.
+	Delimiter []byte
+	// TLS is the client configuration for transports running over TLS. When nil, the transport
+	// defaults apply.
+	TLS *tls.Config
+	// RequestPayload is written to the connection before the content is read.
+	RequestPayload []byte
+	WriteTimeout   time.Duration
+	// Buffered makes the downloader read the whole content before handing it to the callback.
+	Buffered bool
+	// Policy, when set, is enforced on every connection the downloader makes.
+	Policy NetworkPolicy
+}
+
+type DownloaderFactory func(cfg DownloaderConfig) (downloader Downloader, err error)
+
+// DownloaderRegistry maps address schemes to the factories of the transports serving them.
+type DownloaderRegistry struct {
+	mu        sync.RWMutex
+	factories map[string]DownloaderFactory
+}
+
+func NewDownloaderRegistry() *DownloaderRegistry {
+	return &DownloaderRegistry{
+		factories: make(map[string]DownloaderFactory),
+	}
+}
+
+// Register makes the factory available for addresses with the given scheme. Registering
+// the same scheme twice replaces the previous factory.
+func (r *DownloaderRegistry) Register(scheme string, factory DownloaderFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.factories[strings.ToLower(scheme)] = factory
+}
+
+// Create resolves the factory from the scheme prefix of cfg.Address and builds a Downloader
+// with the prefix stripped from the address.
+func (r *DownloaderRegistry) Create(cfg DownloaderConfig) (downloader Downloader, err error) {
+	cfg.Scheme, cfg.Address = SplitAddress(cfg.Address)
+
+	r.mu.RLock()
+	factory, ok := r.factories[cfg.Scheme]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownScheme, cfg.Scheme)
+	}
+
+	return factory(cfg)
+}
+
+// SplitAddress separates "scheme://rest" into its parts. Addresses without a prefix, such as
+// bare "host:port", are reported with DefaultScheme.
+func SplitAddress(address string) (scheme, rest string) {
+	i := strings.Index(address, schemeSeparator)
+	if i < 0 {
+		return DefaultScheme, address
+	}
+
+	return strings.ToLower(address[:i]), address[i+len(schemeSeparator):]
+}
+
+// DefaultDownloaderRegistry is the registry transports add themselves to on import.
+var DefaultDownloaderRegistry = NewDownloaderRegistry()
+
+// RegisterDownloader registers the factory in DefaultDownloaderRegistry.
+func RegisterDownloader(scheme string, factory DownloaderFactory) {
+	DefaultDownloaderRegistry.Register(scheme, factory)
+}
+
+// CreateDownloader builds a Downloader using DefaultDownloaderRegistry.
+func CreateDownloader(cfg DownloaderConfig) (downloader Downloader, err error) {
+	return DefaultDownloaderRegistry.Create(cfg)
+}
@@ -0,0 +1,101 @@
+package afihtmlparser
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloaderRegistry_Create(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		schemes []string
+		cfg     DownloaderConfig
+
+		wantErr  bool
+		expected DownloaderConfig
+	}{
+		{
+			name:    "bare address resolves to default scheme",
+			enabled: true,
+
+			schemes: []string{"tcp"},
+			cfg: DownloaderConfig{
+				Address:     "127.0.0.1:8080",
+				DialTimeout: time.Second,
+			},
+
+			expected: DownloaderConfig{
+				Scheme:      "tcp",
+				Address:     "127.0.0.1:8080",
+				DialTimeout: time.Second,
+			},
+		},
+		{
+			name:    "address with scheme prefix",
+			enabled: true,
+
+			schemes: []string{"tcp", "http"},
+			cfg: DownloaderConfig{
+				Address: "HTTP://example.com/page",
+			},
+
+			expected: DownloaderConfig{
+				Scheme:  "http",
+				Address: "example.com/page",
+			},
+		},
+		{
+			name:    "unknown scheme",
+			enabled: true,
+
+			schemes: []string{"tcp"},
+			cfg: DownloaderConfig{
+				Address: "ftp://example.com",
+			},
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			var (
+				registry = NewDownloaderRegistry()
+				actual   DownloaderConfig
+			)
+
+			for _, scheme := range test.schemes {
+				registry.Register(scheme, func(cfg DownloaderConfig) (Downloader, error) {
+					actual = cfg
+
+					return &MockDownloader{}, nil
+				})
+			}
+
+			downloader, err := registry.Create(test.cfg)
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if test.wantErr {
+				assert.True(t, errors.Is(err, ErrUnknownScheme))
+				assert.Nil(t, downloader)
+
+				return
+			}
+
+			assert.NotNil(t, downloader)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
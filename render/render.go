@@ -0,0 +1,97 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"golang.org/x/net/html"
+)
+
+// HTML returns the HTML node rendered in the mode with the entities of the markup unescaped as
+// it's always been done. The text is returned as is, but the markup isn't safe to parse again:
+// the &lt;script&gt; text turns into a tag. EscapedHTML is to be used instead.
+func HTML(n *html.Node, mode ahp.RenderMode) (string, error) {
+	if mode == ahp.RenderModeText {
+		return Text(n), nil
+	}
+
+	s, err := EscapedHTML(n, mode)
+	if err != nil {
+		return "", err
+	}
+
+	return html.UnescapeString(s), nil
+}
+
+// EscapedHTML returns the HTML node rendered in the mode keeping the markup escaped, so it's
+// parsed back into the same nodes. The text is decoded as it's not a markup.
+func EscapedHTML(n *html.Node, mode ahp.RenderMode) (string, error) {
+	buf := &bytes.Buffer{}
+
+	switch mode {
+	case ahp.RenderModeText:
+		return Text(n), nil
+	case ahp.RenderModeInnerHTML:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			// The contents of the raw text elements are rendered by their parents unescaped.
+			if c.Type == html.TextNode && rawText(n) {
+				buf.WriteString(c.Data)
+
+				continue
+			}
+
+			if err := html.Render(buf, c); err != nil {
+				return "", err
+			}
+		}
+	default:
+		if err := html.Render(buf, n); err != nil {
+			return "", err
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// Text returns the text content of the HTML node with the runs of whitespace collapsed into a
+// single space and trimmed.
+func Text(n *html.Node) string {
+	buf := &strings.Builder{}
+
+	var text func(n *html.Node)
+
+	text = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			buf.WriteString(n.Data)
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			text(c)
+		}
+	}
+
+	text(n)
+
+	return Collapse(buf.String())
+}
+
+// Collapse collapses the runs of whitespace of the text into a single space and trims it.
+func Collapse(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// rawText reports whether the contents of the element are rendered unescaped, the list matches
+// the one of html.Render.
+func rawText(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+
+	switch n.Data {
+	case "iframe", "noembed", "noframes", "noscript", "plaintext", "script", "style", "xmp":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,228 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/html"
+)
+
+func TestHTML(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		mode ahp.RenderMode
+
+		expected string
+	}{
+		{
+			name:    "default",
+			enabled: true,
+
+			expected: `<div class="item">
+	<b>Phone</b>   case
+	<!-- hidden --><i>new</i>
+</div>`,
+		},
+		{
+			name:    "outer html",
+			enabled: true,
+
+			mode: ahp.RenderModeOuterHTML,
+
+			expected: `<div class="item">
+	<b>Phone</b>   case
+	<!-- hidden --><i>new</i>
+</div>`,
+		},
+		{
+			name:    "inner html",
+			enabled: true,
+
+			mode: ahp.RenderModeInnerHTML,
+
+			expected: `
+	<b>Phone</b>   case
+	<!-- hidden --><i>new</i>
+`,
+		},
+		{
+			name:    "text",
+			enabled: true,
+
+			mode: ahp.RenderModeText,
+
+			expected: `Phone case new`,
+		},
+	}
+
+	doc, err := html.Parse(strings.NewReader(`<div class="item">
+	<b>Phone</b>   case
+	<!-- hidden --><i>new</i>
+</div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := doc.FirstChild.LastChild.FirstChild
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actual, err := HTML(n, test.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEscapedHTML(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		document string
+		mode     ahp.RenderMode
+
+		expected         string
+		expectedUnsafely string
+	}{
+		{
+			name:    "entities",
+			enabled: true,
+
+			document: `<p>&lt;script&gt;alert(1)&lt;/script&gt; &amp;amp; &copy;&nbsp;2020</p>`,
+
+			expected:         `<p>&lt;script&gt;alert(1)&lt;/script&gt; &amp;amp; ©` + " " + `2020</p>`,
+			expectedUnsafely: `<p><script>alert(1)</script> &amp; ©` + " " + `2020</p>`,
+		},
+		{
+			name:    "attribute with quotes",
+			enabled: true,
+
+			document: `<p title='say "hi" &amp; &#39;bye&#39;'>text</p>`,
+
+			expected:         `<p title="say &#34;hi&#34; &amp; &#39;bye&#39;">text</p>`,
+			expectedUnsafely: `<p title="say "hi" & 'bye'">text</p>`,
+		},
+		{
+			name:    "script",
+			enabled: true,
+
+			document: `<p><script>if (a < b && c > "&lt;") {}</script></p>`,
+
+			expected:         `<p><script>if (a < b && c > "&lt;") {}</script></p>`,
+			expectedUnsafely: `<p><script>if (a < b && c > "<") {}</script></p>`,
+		},
+		{
+			name:    "inner html of style",
+			enabled: true,
+
+			document: `<p><style>a > b { content: "&amp;" }</style></p>`,
+			mode:     ahp.RenderModeInnerHTML,
+
+			expected:         `<style>a > b { content: "&amp;" }</style>`,
+			expectedUnsafely: `<style>a > b { content: "&" }</style>`,
+		},
+		{
+			name:    "decoded text",
+			enabled: true,
+
+			document: `<p>&lt;b&gt; &amp;amp; <script>a &lt; b</script></p>`,
+			mode:     ahp.RenderModeText,
+
+			expected:         `<b> &amp; a &lt; b`,
+			expectedUnsafely: `<b> &amp; a &lt; b`,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			doc, err := html.Parse(strings.NewReader(test.document))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// html > body > p
+			n := doc.FirstChild.LastChild.FirstChild
+
+			actual, err := EscapedHTML(n, test.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, test.expected, actual)
+
+			actualUnsafely, err := HTML(n, test.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, test.expectedUnsafely, actualUnsafely)
+		})
+	}
+}
+
+func TestEscapedHTML_InnerHTMLOfRawText(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<script>if (a < b && c > "&lt;") {}</script>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// html > head > script
+	actual, err := EscapedHTML(doc.FirstChild.FirstChild.FirstChild, ahp.RenderModeInnerHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, `if (a < b && c > "&lt;") {}`, actual)
+}
+
+func TestEscapedHTML_RoundTrip(t *testing.T) {
+	const document = `<div title="a &#34;quoted&#34; &amp; &lt;title&gt;">&lt;script&gt;alert(1)&lt;/script&gt;` +
+		`<script>if (a < b) {}</script><textarea>&lt;/textarea&gt;</textarea></div>`
+
+	doc, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rendered, err := EscapedHTML(doc.FirstChild.LastChild.FirstChild, ahp.RenderModeOuterHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reparsed, err := html.Parse(strings.NewReader(rendered))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := EscapedHTML(reparsed.FirstChild.LastChild.FirstChild, ahp.RenderModeOuterHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, rendered, actual)
+	assert.Equal(t, 3, countChildren(reparsed.FirstChild.LastChild.FirstChild))
+}
+
+func countChildren(n *html.Node) (count int) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		count++
+	}
+
+	return count
+}
@@ -0,0 +1,109 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+)
+
+// Backoff defines the delay between attempts: Initial multiplied by Multiplier after every
+// attempt and capped by Max. Jitter is the fraction of the delay randomly taken off it.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+// Delay returns the delay after the attempt, attempts are numbered from 1.
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := float64(b.Initial) * math.Pow(b.Multiplier, float64(attempt-1))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+
+	delay -= delay * b.Jitter * rand.Float64() //nolint:gosec
+
+	return time.Duration(delay)
+}
+
+// Downloader retries the download with the underlying downloader while the error is retryable
+// and attempts are left.
+type Downloader struct {
+	d        ahp.ContextDownloader
+	attempts int
+	backoff  Backoff
+
+	made int
+}
+
+func NewDownloader(d ahp.Downloader, attempts int, backoff Backoff) *Downloader {
+	return &Downloader{
+		d:        ahp.DownloaderWithContext(d),
+		attempts: attempts,
+		backoff:  backoff,
+	}
+}
+
+func (d *Downloader) Download(contentLength int64, timeout time.Duration, callbackFn ahp.DownloadCallback) (err error) {
+	return d.DownloadContext(context.Background(), contentLength, timeout, callbackFn)
+}
+
+// DownloadContext is Download which stops retrying once the context is done.
+func (d *Downloader) DownloadContext(ctx context.Context, contentLength int64, timeout time.Duration,
+	callbackFn ahp.DownloadCallback) (err error) {
+	d.made = 0
+
+	for {
+		d.made++
+
+		err = d.d.DownloadContext(ctx, contentLength, timeout, callbackFn)
+		if err == nil || d.made >= d.attempts || ctx.Err() != nil || !IsRetryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(d.backoff.Delay(d.made))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Attempts returns the number of attempts made by the last download.
+func (d *Downloader) Attempts() int {
+	return d.made
+}
+
+// IsRetryable reports whether the error is transient: the connection was refused or reset, an
+// operation timed out or the content was shorter than expected. Whether the download was given up
+// by the caller is decided by its context, as the timeouts of an attempt match
+// context.DeadlineExceeded too.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,322 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestDownloader_DownloadContext(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		attempts int
+		errs     []error
+
+		wantErr          bool
+		expectedAttempts int
+	}{
+		{
+			name:    "pass with first attempt",
+			enabled: true,
+
+			attempts: 3,
+			errs:     []error{nil},
+
+			expectedAttempts: 1,
+		},
+		{
+			name:    "pass after connection refused",
+			enabled: true,
+
+			attempts: 3,
+			errs:     []error{refused, nil},
+
+			expectedAttempts: 2,
+		},
+		{
+			name:    "pass after read timeout and short read",
+			enabled: true,
+
+			attempts: 3,
+			errs:     []error{timeoutError{}, io.ErrUnexpectedEOF, nil},
+
+			expectedAttempts: 3,
+		},
+		{
+			name:    "attempts exhausted",
+			enabled: true,
+
+			attempts: 3,
+			errs:     []error{refused, refused, refused},
+
+			wantErr:          true,
+			expectedAttempts: 3,
+		},
+		{
+			name:    "permanent error",
+			enabled: true,
+
+			attempts: 3,
+			errs:     []error{errors.New("parse error")},
+
+			wantErr:          true,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			downloader := &ahp.MockDownloader{}
+
+			for _, err := range test.errs {
+				downloader.
+					On("Download", int64(10), time.Second, mock.AnythingOfType("afihtmlparser.DownloadCallback")).
+					Return(err).
+					Once()
+			}
+
+			d := NewDownloader(downloader, test.attempts, Backoff{Initial: time.Millisecond, Multiplier: 2})
+
+			err := d.DownloadContext(context.Background(), 10, time.Second, func(_ io.Reader) error {
+				return nil
+			})
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			assert.Equal(t, test.expectedAttempts, d.Attempts())
+			downloader.AssertExpectations(t)
+		})
+	}
+}
+
+// dialDownloader dials the address with the timeout on every download.
+type dialDownloader struct {
+	address string
+	timeout time.Duration
+}
+
+func (d dialDownloader) Download(_ int64, _ time.Duration, _ ahp.DownloadCallback) (err error) {
+	conn, err := (&net.Dialer{Timeout: d.timeout}).Dial("tcp", d.address)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+func dialTimeout(t *testing.T) error {
+	err := dialDownloader{address: "127.0.0.1:1", timeout: time.Nanosecond}.Download(0, 0, nil)
+	if err == nil {
+		t.Fatal("dial did not time out")
+	}
+
+	return err
+}
+
+func TestDownloader_DownloadContextDialTimeout(t *testing.T) {
+	d := NewDownloader(dialDownloader{address: "127.0.0.1:1", timeout: time.Nanosecond}, 3,
+		Backoff{Initial: time.Millisecond, Multiplier: 2})
+
+	err := d.DownloadContext(context.Background(), 10, time.Second, func(_ io.Reader) error {
+		return nil
+	})
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, 3, d.Attempts())
+}
+
+func TestDownloader_DownloadContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	downloader := &ahp.MockDownloader{}
+	downloader.
+		On("Download", int64(10), time.Second, mock.AnythingOfType("afihtmlparser.DownloadCallback")).
+		Run(func(_ mock.Arguments) { cancel() }).
+		Return(context.DeadlineExceeded).
+		Once()
+
+	d := NewDownloader(downloader, 3, Backoff{Initial: time.Millisecond, Multiplier: 2})
+
+	err := d.DownloadContext(ctx, 10, time.Second, func(_ io.Reader) error {
+		return nil
+	})
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, d.Attempts())
+	downloader.AssertExpectations(t)
+}
+
+func TestIsRetryable(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		err error
+
+		expected bool
+	}{
+		{
+			name:    "connection refused",
+			enabled: true,
+
+			err: &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+
+			expected: true,
+		},
+		{
+			name:    "connection reset",
+			enabled: true,
+
+			err: &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+
+			expected: true,
+		},
+		{
+			name:    "timeout",
+			enabled: true,
+
+			err: &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}},
+
+			expected: true,
+		},
+		{
+			name:    "short read",
+			enabled: true,
+
+			err: fmt.Errorf("parse: %w", io.ErrUnexpectedEOF),
+
+			expected: true,
+		},
+		{
+			name:    "dial timeout",
+			enabled: true,
+
+			err: dialTimeout(t),
+
+			expected: true,
+		},
+		{
+			name:    "deadline exceeded of attempt",
+			enabled: true,
+
+			err: context.DeadlineExceeded,
+
+			expected: true,
+		},
+		{
+			name:    "canceled",
+			enabled: true,
+
+			err: context.Canceled,
+		},
+		{
+			name:    "content too large",
+			enabled: true,
+
+			err: ahp.ErrContentTooLarge,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			assert.Equal(t, test.expected, IsRetryable(test.err))
+		})
+	}
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		backoff Backoff
+		attempt int
+
+		expectedMin time.Duration
+		expectedMax time.Duration
+	}{
+		{
+			name:    "first attempt",
+			enabled: true,
+
+			backoff: Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2},
+			attempt: 1,
+
+			expectedMin: 100 * time.Millisecond,
+			expectedMax: 100 * time.Millisecond,
+		},
+		{
+			name:    "exponential growth",
+			enabled: true,
+
+			backoff: Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2},
+			attempt: 3,
+
+			expectedMin: 400 * time.Millisecond,
+			expectedMax: 400 * time.Millisecond,
+		},
+		{
+			name:    "capped by max",
+			enabled: true,
+
+			backoff: Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2},
+			attempt: 10,
+
+			expectedMin: time.Second,
+			expectedMax: time.Second,
+		},
+		{
+			name:    "jitter",
+			enabled: true,
+
+			backoff: Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2, Jitter: 0.5},
+			attempt: 2,
+
+			expectedMin: 100 * time.Millisecond,
+			expectedMax: 200 * time.Millisecond,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actual := test.backoff.Delay(test.attempt)
+
+			assert.GreaterOrEqual(t, int64(actual), int64(test.expectedMin))
+			assert.LessOrEqual(t, int64(actual), int64(test.expectedMax))
+		})
+	}
+}
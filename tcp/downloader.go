@@ -2,41 +2,266 @@ package tcp
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
+	"syscall"
 	"time"
 
 	ahp "github.com/morozovcookie/afihtmlparser"
 )
 
+var ErrTLSHandshake = errors.New("tls handshake error")
+
 type Downloader struct {
-	address string
-	timeout time.Duration
+	network   string
+	address   string
+	timeout   time.Duration
+	framer    Framer
+	tlsConfig *tls.Config
+
+	payload      []byte
+	writeTimeout time.Duration
+
+	buffered bool
+	policy   ahp.NetworkPolicy
+}
+
+type Option func(d *Downloader)
+
+// WithNetwork sets the network the address belongs to, "tcp" by default. With "unix" and
+// "unixpacket" the address is the path of the socket.
+func WithNetwork(network string) Option {
+	return func(d *Downloader) {
+		d.network = network
+	}
+}
+
+// WithReadMode sets how the end of the content is detected. ahp.ReadModeFixed is used by default.
+func WithReadMode(mode ahp.ReadMode) Option {
+	return func(d *Downloader) {
+		if mode == ahp.ReadModeEOF {
+			d.framer = UntilEOF{}
+
+			return
+		}
+
+		d.framer = FixedLength{}
+	}
+}
+
+// WithFramer sets the strategy extracting the content from the stream.
+func WithFramer(framer Framer) Option {
+	return func(d *Downloader) {
+		d.framer = framer
+	}
+}
+
+// WithTLS wraps the connection into TLS. When the configuration has no ServerName, the host part
+// of the address is verified.
+func WithTLS(cfg *tls.Config) Option {
+	return func(d *Downloader) {
+		d.tlsConfig = cfg
+	}
+}
+
+// WithRequest makes the downloader write the payload to the connection before reading the content.
+func WithRequest(payload []byte, timeout time.Duration) Option {
+	return func(d *Downloader) {
+		d.payload = payload
+		d.writeTimeout = timeout
+	}
+}
+
+// WithBuffering makes the downloader read the whole content before calling the callback, which
+// then receives an io.ReadSeeker. By default the callback reads straight from the connection.
+func WithBuffering(buffered bool) Option {
+	return func(d *Downloader) {
+		d.buffered = buffered
+	}
+}
+
+// WithPolicy makes the downloader refuse connections violating the network policy.
+func WithPolicy(policy ahp.NetworkPolicy) Option {
+	return func(d *Downloader) {
+		d.policy = policy
+	}
 }
 
-func NewDownloader(address string, timeout time.Duration) *Downloader {
-	return &Downloader{
+func NewDownloader(address string, timeout time.Duration, opts ...Option) *Downloader {
+	d := &Downloader{
+		network: "tcp",
 		address: address,
 		timeout: timeout,
+		framer:  FixedLength{},
+	}
+
+	for _, opt := range opts {
+		opt(d)
 	}
+
+	return d
 }
 
+// Download reads the content framed by the downloader Framer from the connection. contentLength
+// is the exact size of the content for FixedLength and the upper bound of its size otherwise.
+// The read deadline applies to the whole download including the time spent in the callback.
 func (d *Downloader) Download(contentLength int64, timeout time.Duration, callbackFn ahp.DownloadCallback) (err error) {
-	conn, err := net.DialTimeout("tcp", d.address, d.timeout)
+	return d.DownloadContext(context.Background(), contentLength, timeout, callbackFn)
+}
+
+// DownloadContext is Download which closes the connection once the context is done. In that case
+// the context error is returned.
+func (d *Downloader) DownloadContext(ctx context.Context, contentLength int64, timeout time.Duration,
+	callbackFn ahp.DownloadCallback) (err error) {
+	defer func() {
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+	}()
+
+	dialer, err := d.dialer()
+	if err != nil {
+		return err
+	}
+
+	conn, err := dialer.DialContext(ctx, d.network, d.address)
 	if err != nil {
 		return err
 	}
 
 	defer conn.Close()
 
+	stop := closeOnDone(ctx, conn)
+	defer stop()
+
+	return d.download(conn, contentLength, timeout, callbackFn)
+}
+
+func (d *Downloader) dialer() (dialer *net.Dialer, err error) {
+	dialer = &net.Dialer{Timeout: d.timeout}
+
+	if d.policy == nil {
+		return dialer, nil
+	}
+
+	if host, _, err := net.SplitHostPort(d.address); err == nil {
+		if err = d.policy.CheckHost(host); err != nil {
+			return nil, err
+		}
+	}
+
+	dialer.Control = func(network, address string, _ syscall.RawConn) error {
+		return d.policy.CheckAddr(network, address)
+	}
+
+	return dialer, nil
+}
+
+func (d *Downloader) download(conn net.Conn, contentLength int64, timeout time.Duration,
+	callbackFn ahp.DownloadCallback) (err error) {
+	if conn, err = d.handshake(conn); err != nil {
+		return err
+	}
+
+	if err = d.writeRequest(conn); err != nil {
+		return err
+	}
+
 	if err = conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
 		return
 	}
 
-	buf := &bytes.Buffer{}
-	if _, err = io.CopyN(buf, conn, contentLength); err != nil {
+	var r io.Reader = conn
+	if d.network == "unixpacket" {
+		r = newPacketReader(conn, contentLength)
+	}
+
+	content, err := d.framer.Frame(r, contentLength)
+	if err != nil {
+		return err
+	}
+
+	if d.buffered {
+		buf := &bytes.Buffer{}
+		if _, err = io.Copy(buf, content); err != nil {
+			return err
+		}
+
+		return callbackFn(bytes.NewReader(buf.Bytes()))
+	}
+
+	if err = callbackFn(content); err != nil {
+		return err
+	}
+
+	// The callback may stop reading early, the rest of the content is still checked against
+	// the framing.
+	_, err = io.Copy(ioutil.Discard, content)
+
+	return err
+}
+
+func (d *Downloader) writeRequest(conn net.Conn) (err error) {
+	if len(d.payload) == 0 {
+		return nil
+	}
+
+	if err = conn.SetWriteDeadline(time.Now().Add(d.writeTimeout)); err != nil {
 		return err
 	}
 
-	return callbackFn(buf)
+	_, err = conn.Write(d.payload)
+
+	return err
+}
+
+func (d *Downloader) handshake(conn net.Conn) (_ net.Conn, err error) {
+	if d.tlsConfig == nil {
+		return conn, nil
+	}
+
+	cfg := d.tlsConfig.Clone()
+	if cfg.ServerName == "" {
+		if cfg.ServerName, _, err = net.SplitHostPort(d.address); err != nil {
+			return nil, err
+		}
+	}
+
+	tlsConn := tls.Client(conn, cfg)
+
+	if err = tlsConn.SetDeadline(time.Now().Add(d.timeout)); err == nil {
+		err = tlsConn.Handshake()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTLSHandshake, err)
+	}
+
+	return tlsConn, tlsConn.SetDeadline(time.Time{})
+}
+
+// closeOnDone closes the connection once the context is done, which interrupts any pending I/O.
+func closeOnDone(ctx context.Context, conn net.Conn) (stop func()) {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
 }
@@ -1,15 +1,30 @@
 package tcp
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"errors"
 	"io"
+	"io/ioutil"
+	"math/big"
 	"math/rand"
 	"net"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
 
 	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/morozovcookie/afihtmlparser/policy"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestDownloader_Download(t *testing.T) {
@@ -23,6 +38,7 @@ func TestDownloader_Download(t *testing.T) {
 		acceptConnection func(net.Listener) error
 		stopServer       func(net.Listener) error
 
+		readMode      ahp.ReadMode
 		contentLength int64
 		timeout       time.Duration
 		callback      ahp.DownloadCallback
@@ -80,6 +96,112 @@ func TestDownloader_Download(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			name:    "pass with eof read mode",
+			enabled: true,
+
+			dialTimeout: time.Minute,
+
+			startServer: func(address string) (net.Listener, error) {
+				ln, err := net.Listen("tcp", address)
+				if err != nil {
+					return ln, err
+				}
+
+				return ln, nil
+			},
+			acceptConnection: func(ln net.Listener) error {
+				if ln == nil {
+					return nil
+				}
+
+				conn, err := ln.Accept()
+				if err != nil {
+					if err == io.EOF {
+						return nil
+					}
+
+					return err
+				}
+
+				defer conn.Close()
+
+				_, err = conn.Write([]byte(`1111111111`))
+				if err != nil {
+					return err
+				}
+
+				return nil
+			},
+			stopServer: func(ln net.Listener) error {
+				if ln == nil {
+					return nil
+				}
+
+				return ln.Close()
+			},
+
+			readMode:      ahp.ReadModeEOF,
+			contentLength: 100,
+			timeout:       time.Second,
+			callback: func(r io.Reader) (err error) {
+				return nil
+			},
+		},
+		{
+			name:    "content too large in eof read mode",
+			enabled: true,
+
+			dialTimeout: time.Minute,
+
+			startServer: func(address string) (net.Listener, error) {
+				ln, err := net.Listen("tcp", address)
+				if err != nil {
+					return ln, err
+				}
+
+				return ln, nil
+			},
+			acceptConnection: func(ln net.Listener) error {
+				if ln == nil {
+					return nil
+				}
+
+				conn, err := ln.Accept()
+				if err != nil {
+					if err == io.EOF {
+						return nil
+					}
+
+					return err
+				}
+
+				defer conn.Close()
+
+				_, err = conn.Write([]byte(`1111111111`))
+				if err != nil {
+					return err
+				}
+
+				return nil
+			},
+			stopServer: func(ln net.Listener) error {
+				if ln == nil {
+					return nil
+				}
+
+				return ln.Close()
+			},
+
+			readMode:      ahp.ReadModeEOF,
+			contentLength: 5,
+			timeout:       time.Second,
+			callback: func(r io.Reader) (err error) {
+				return nil
+			},
+
+			wantErr: true,
+		},
 		{
 			name:    "dial error",
 			enabled: true,
@@ -226,7 +348,8 @@ func TestDownloader_Download(t *testing.T) {
 				ch <- accept(ln)
 			}(ln, test.acceptConnection, errCh)
 
-			err = NewDownloader(addr, test.dialTimeout).Download(test.contentLength, test.timeout, test.callback)
+			err = NewDownloader(addr, test.dialTimeout, WithReadMode(test.readMode)).
+				Download(test.contentLength, test.timeout, test.callback)
 
 			if stopErr := test.stopServer(ln); stopErr != nil {
 				t.Fatal(stopErr)
@@ -242,3 +365,1013 @@ func TestDownloader_Download(t *testing.T) {
 		})
 	}
 }
+
+func TestDownloader_DownloadWithFramer(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		framer  Framer
+		payload []byte
+
+		contentLength int64
+
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:    "fixed length",
+			enabled: true,
+
+			framer:  FixedLength{},
+			payload: []byte(`<li>blabla</li>tail`),
+
+			contentLength: 15,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "until eof",
+			enabled: true,
+
+			framer:  UntilEOF{},
+			payload: []byte(`<li>blabla</li>`),
+
+			contentLength: 100,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "uint16 length prefix",
+			enabled: true,
+
+			framer:  Uint16Prefix,
+			payload: append([]byte{0x00, 0x0F}, []byte(`<li>blabla</li>tail`)...),
+
+			contentLength: 100,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "uint32 length prefix",
+			enabled: true,
+
+			framer:  Uint32Prefix,
+			payload: append([]byte{0x00, 0x00, 0x00, 0x0F}, []byte(`<li>blabla</li>tail`)...),
+
+			contentLength: 100,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "varint length prefix",
+			enabled: true,
+
+			framer:  VarintPrefix,
+			payload: append([]byte{0x0F}, []byte(`<li>blabla</li>tail`)...),
+
+			contentLength: 100,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "length prefix exceeds content length",
+			enabled: true,
+
+			framer:  Uint32Prefix,
+			payload: append([]byte{0x00, 0x00, 0x00, 0x0F}, []byte(`<li>blabla</li>`)...),
+
+			contentLength: 10,
+
+			wantErr: true,
+		},
+		{
+			name:    "truncated length header",
+			enabled: true,
+
+			framer:  Uint32Prefix,
+			payload: []byte{0x00, 0x00},
+
+			contentLength: 100,
+
+			wantErr: true,
+		},
+		{
+			name:    "truncated length prefixed content",
+			enabled: true,
+
+			framer:  Uint16Prefix,
+			payload: append([]byte{0x00, 0x0F}, []byte(`<li>bla`)...),
+
+			contentLength: 100,
+
+			wantErr: true,
+		},
+		{
+			name:    "null delimiter",
+			enabled: true,
+
+			framer:  Delimiter{0x00},
+			payload: []byte("<li>blabla</li>\x00tail"),
+
+			contentLength: 100,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "multi-byte delimiter",
+			enabled: true,
+
+			framer:  Delimiter("\r\n.\r\n"),
+			payload: []byte("<li>blabla</li>\r\n\r\n.\r\ntail"),
+
+			contentLength: 100,
+
+			expected: "<li>blabla</li>\r\n",
+		},
+		{
+			name:    "missing delimiter",
+			enabled: true,
+
+			framer:  Delimiter("\r\n.\r\n"),
+			payload: []byte("<li>blabla</li>\r\n"),
+
+			contentLength: 100,
+
+			wantErr: true,
+		},
+		{
+			name:    "delimited content exceeds content length",
+			enabled: true,
+
+			framer:  Delimiter{0x00},
+			payload: []byte("<li>blabla</li>\x00"),
+
+			contentLength: 10,
+
+			wantErr: true,
+		},
+		{
+			name:    "empty delimiter",
+			enabled: true,
+
+			framer:  Delimiter{},
+			payload: []byte("<li>blabla</li>"),
+
+			contentLength: 100,
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer ln.Close()
+
+			go func(ln net.Listener, payload []byte) {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+
+				defer conn.Close()
+
+				_, _ = conn.Write(payload)
+			}(ln, test.payload)
+
+			var actual string
+
+			err = NewDownloader(ln.Addr().String(), time.Second, WithFramer(test.framer)).
+				Download(test.contentLength, time.Second, func(r io.Reader) (err error) {
+					b, err := ioutil.ReadAll(r)
+					actual = string(b)
+
+					return err
+				})
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestDownloader_DownloadWithTLS(t *testing.T) {
+	var (
+		serverCert = newTestCertificate(t)
+		clientCert = newTestCertificate(t)
+
+		serverPool = x509.NewCertPool()
+		clientPool = x509.NewCertPool()
+	)
+
+	serverPool.AddCert(serverCert.Leaf)
+	clientPool.AddCert(clientCert.Leaf)
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		serverConfig *tls.Config
+		clientConfig *tls.Config
+
+		wantErr          bool
+		wantHandshakeErr bool
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			serverConfig: &tls.Config{
+				Certificates: []tls.Certificate{serverCert},
+			},
+			clientConfig: &tls.Config{
+				RootCAs: serverPool,
+			},
+		},
+		{
+			name:    "pass with mutual tls",
+			enabled: true,
+
+			serverConfig: &tls.Config{
+				Certificates: []tls.Certificate{serverCert},
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				ClientCAs:    clientPool,
+			},
+			clientConfig: &tls.Config{
+				RootCAs:      serverPool,
+				Certificates: []tls.Certificate{clientCert},
+			},
+		},
+		{
+			name:    "pass with insecure skip verify",
+			enabled: true,
+
+			serverConfig: &tls.Config{
+				Certificates: []tls.Certificate{serverCert},
+			},
+			clientConfig: &tls.Config{
+				InsecureSkipVerify: true, //nolint:gosec
+			},
+		},
+		{
+			name:    "unknown certificate authority",
+			enabled: true,
+
+			serverConfig: &tls.Config{
+				Certificates: []tls.Certificate{serverCert},
+			},
+			clientConfig: &tls.Config{
+				RootCAs: clientPool,
+			},
+
+			wantErr:          true,
+			wantHandshakeErr: true,
+		},
+		{
+			name:    "minimum version is not supported by server",
+			enabled: true,
+
+			serverConfig: &tls.Config{
+				Certificates: []tls.Certificate{serverCert},
+				MaxVersion:   tls.VersionTLS12,
+			},
+			clientConfig: &tls.Config{
+				RootCAs:    serverPool,
+				MinVersion: tls.VersionTLS13,
+			},
+
+			wantErr:          true,
+			wantHandshakeErr: true,
+		},
+		{
+			name:    "missing client certificate",
+			enabled: true,
+
+			serverConfig: &tls.Config{
+				Certificates: []tls.Certificate{serverCert},
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				ClientCAs:    clientPool,
+			},
+			clientConfig: &tls.Config{
+				RootCAs: serverPool,
+			},
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			ln, err := tls.Listen("tcp", "127.0.0.1:0", test.serverConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer ln.Close()
+
+			go func(ln net.Listener) {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+
+				defer conn.Close()
+
+				_, _ = conn.Write([]byte(`1111111111`))
+			}(ln)
+
+			err = NewDownloader(ln.Addr().String(), time.Second, WithTLS(test.clientConfig)).
+				Download(10, time.Second, func(r io.Reader) (err error) {
+					return nil
+				})
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			assert.Equal(t, test.wantHandshakeErr, errors.Is(err, ErrTLSHandshake))
+		})
+	}
+}
+
+func newTestCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), crand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(rand.Int63()),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(crand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+}
+
+func TestDownloader_DownloadWithRequest(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		payload []byte
+
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			payload: []byte("GET page-42\n"),
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "unknown command",
+			enabled: true,
+
+			payload: []byte("GET page-43\n"),
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer ln.Close()
+
+			go func(ln net.Listener) {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+
+				defer conn.Close()
+
+				command, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil || command != "GET page-42\n" {
+					return
+				}
+
+				_, _ = conn.Write([]byte(`<li>blabla</li>`))
+			}(ln)
+
+			var actual string
+
+			err = NewDownloader(ln.Addr().String(), time.Second, WithRequest(test.payload, time.Second)).
+				Download(15, time.Second, func(r io.Reader) (err error) {
+					b, err := ioutil.ReadAll(r)
+					actual = string(b)
+
+					return err
+				})
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestDownloader_DownloadWithBuffering(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		buffered      bool
+		contentLength int64
+		callback      ahp.DownloadCallback
+
+		wantErr bool
+	}{
+		{
+			name:    "streaming reader is not seekable",
+			enabled: true,
+
+			contentLength: 10,
+			callback: func(r io.Reader) (err error) {
+				if _, ok := r.(io.Seeker); ok {
+					return errors.New("unexpected seeker")
+				}
+
+				return nil
+			},
+		},
+		{
+			name:    "buffered reader is seekable",
+			enabled: true,
+
+			buffered:      true,
+			contentLength: 10,
+			callback: func(r io.Reader) (err error) {
+				if _, ok := r.(io.Seeker); !ok {
+					return errors.New("seeker expected")
+				}
+
+				return nil
+			},
+		},
+		{
+			name:    "short content is detected after callback",
+			enabled: true,
+
+			contentLength: 20,
+			callback: func(r io.Reader) (err error) {
+				return nil
+			},
+
+			wantErr: true,
+		},
+		{
+			name:    "short content in buffered mode",
+			enabled: true,
+
+			buffered:      true,
+			contentLength: 20,
+			callback: func(r io.Reader) (err error) {
+				return nil
+			},
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer ln.Close()
+
+			go func(ln net.Listener) {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+
+				defer conn.Close()
+
+				_, _ = conn.Write([]byte(`1111111111`))
+			}(ln)
+
+			err = NewDownloader(ln.Addr().String(), time.Second, WithBuffering(test.buffered)).
+				Download(test.contentLength, time.Second, test.callback)
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func BenchmarkDownloader_Download(b *testing.B) {
+	for _, size := range []int{1 << 20, 16 << 20} {
+		for _, buffered := range []bool{false, true} {
+			name := strconv.Itoa(size>>20) + "MiB/streaming"
+			if buffered {
+				name = strconv.Itoa(size>>20) + "MiB/buffered"
+			}
+
+			b.Run(name, func(b *testing.B) {
+				benchmarkDownload(b, size, buffered)
+			})
+		}
+	}
+}
+
+func benchmarkDownload(b *testing.B, size int, buffered bool) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	defer ln.Close()
+
+	payload := bytes.Repeat([]byte(`<li>blabla</li>`), size/15+1)[:size]
+
+	go func(ln net.Listener) {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				_, _ = conn.Write(payload)
+			}(conn)
+		}
+	}(ln)
+
+	var (
+		d = NewDownloader(ln.Addr().String(), time.Second, WithBuffering(buffered))
+
+		callback = func(r io.Reader) (err error) {
+			_, err = io.Copy(ioutil.Discard, r)
+
+			return err
+		}
+	)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err = d.Download(int64(size), time.Minute, callback); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDownloader_DownloadContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		ctx func() (context.Context, context.CancelFunc)
+
+		expected error
+	}{
+		{
+			name:    "canceled before dial",
+			enabled: true,
+
+			ctx: func() (context.Context, context.CancelFunc) {
+				return canceled, func() {}
+			},
+
+			expected: context.Canceled,
+		},
+		{
+			name:    "deadline exceeded while reading",
+			enabled: true,
+
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+
+			expected: context.DeadlineExceeded,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer ln.Close()
+
+			done := make(chan struct{})
+			defer close(done)
+
+			go func(ln net.Listener) {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+
+				defer conn.Close()
+
+				<-done
+			}(ln)
+
+			ctx, cancel := test.ctx()
+			defer cancel()
+
+			err = NewDownloader(ln.Addr().String(), time.Second).
+				DownloadContext(ctx, 10, time.Minute, func(r io.Reader) (err error) {
+					_, err = ioutil.ReadAll(r)
+
+					return err
+				})
+
+			assert.Equal(t, test.expected, err)
+		})
+	}
+}
+
+func TestDownloader_DownloadWithPolicy(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		cfg policy.Config
+
+		wantErr bool
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			cfg: policy.Config{
+				AllowCIDRs: []string{"127.0.0.0/8"},
+			},
+		},
+		{
+			name:    "denied address",
+			enabled: true,
+
+			cfg: policy.Config{
+				DenyPrivate: true,
+			},
+
+			wantErr: true,
+		},
+		{
+			name:    "port is not allowed",
+			enabled: true,
+
+			cfg: policy.Config{
+				Ports: []string{"80", "443"},
+			},
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			p, err := policy.New(test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer ln.Close()
+
+			go func(ln net.Listener) {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+
+				defer conn.Close()
+
+				_, _ = conn.Write([]byte(`1111111111`))
+			}(ln)
+
+			err = NewDownloader(ln.Addr().String(), time.Second, WithPolicy(p)).
+				Download(10, time.Second, func(r io.Reader) (err error) {
+					_, err = io.Copy(ioutil.Discard, r)
+
+					return err
+				})
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+			}
+
+			if test.wantErr {
+				assert.True(t, errors.Is(err, ahp.ErrNetworkPolicy))
+			}
+		})
+	}
+}
+
+func TestDownloader_DownloadWithNetwork(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		network  string
+		framer   Framer
+		messages [][]byte
+
+		contentLength int64
+
+		wantErr  bool
+		expected string
+	}{
+		{
+			name:    "unix",
+			enabled: true,
+
+			network:  "unix",
+			messages: [][]byte{[]byte(`<li>blabla</li>`)},
+
+			contentLength: 15,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "unixpacket with content split into messages",
+			enabled: true,
+
+			network:  "unixpacket",
+			messages: [][]byte{[]byte(`<li>bla`), []byte(`bla</li>`)},
+
+			contentLength: 15,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "unixpacket with header in the content message",
+			enabled: true,
+
+			network:  "unixpacket",
+			framer:   Uint16Prefix,
+			messages: [][]byte{append([]byte{0, 15}, `<li>blabla</li>`...)},
+
+			contentLength: 100,
+
+			expected: `<li>blabla</li>`,
+		},
+		{
+			name:    "unixpacket with short content",
+			enabled: true,
+
+			network:  "unixpacket",
+			messages: [][]byte{[]byte(`<li>blabla</li>`)},
+
+			contentLength: 20,
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			dir, err := ioutil.TempDir("", "ahp")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer os.RemoveAll(dir)
+
+			address := filepath.Join(dir, "test.sock")
+
+			ln, err := net.Listen(test.network, address)
+			if err != nil {
+				t.Skip(err)
+			}
+
+			defer ln.Close()
+
+			go func(ln net.Listener) {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+
+				defer conn.Close()
+
+				for _, message := range test.messages {
+					if _, err = conn.Write(message); err != nil {
+						return
+					}
+				}
+			}(ln)
+
+			opts := []Option{WithNetwork(test.network)}
+			if test.framer != nil {
+				opts = append(opts, WithFramer(test.framer))
+			}
+
+			var actual string
+
+			err = NewDownloader(address, time.Second, opts...).
+				Download(test.contentLength, time.Second, func(r io.Reader) (err error) {
+					b, err := ioutil.ReadAll(r)
+					actual = string(b)
+
+					return err
+				})
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if !test.wantErr {
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDownloader_DownloadUnixWithPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ahp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	address := filepath.Join(dir, "test.sock")
+
+	ln, err := net.Listen("unix", address)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	defer ln.Close()
+
+	go func(ln net.Listener) {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		_, _ = conn.Write([]byte(`1111111111`))
+	}(ln)
+
+	p, err := policy.New(policy.Config{DenyPrivate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Factory(ahp.DownloaderConfig{
+		Scheme:      UnixScheme,
+		Address:     address,
+		DialTimeout: time.Second,
+		Policy:      p,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.Download(10, time.Second, func(r io.Reader) (err error) {
+		_, err = io.Copy(ioutil.Discard, r)
+
+		return err
+	})
+
+	assert.True(t, errors.Is(err, ahp.ErrNetworkPolicy))
+}
+
+func TestNewUnixFactory(t *testing.T) {
+	tt := []struct {
+		name    string
+		enabled bool
+
+		address string
+
+		wantErr bool
+	}{
+		{
+			name:    "allowed socket",
+			enabled: true,
+
+			address: "/var/run/renderer.sock",
+		},
+		{
+			name:    "allowed socket with unclean path",
+			enabled: true,
+
+			address: "/var/run/../run/renderer.sock",
+		},
+		{
+			name:    "allowed abstract socket",
+			enabled: true,
+
+			address: "@renderer",
+		},
+		{
+			name:    "socket is not allowed",
+			enabled: true,
+
+			address: "/var/run/docker.sock",
+
+			wantErr: true,
+		},
+	}
+
+	factory := NewUnixFactory([]string{"/var/run/renderer.sock", "@renderer"})
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			_, err := factory(ahp.DownloaderConfig{Scheme: UnixScheme, Address: test.address})
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+			}
+
+			if test.wantErr {
+				assert.True(t, errors.Is(err, ErrSocketNotAllowed))
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package tcp
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+)
+
+const (
+	Scheme           = "tcp"
+	TLSScheme        = "tls"
+	UnixScheme       = "unix"
+	UnixPacketScheme = "unixpacket"
+)
+
+var ErrSocketNotAllowed = errors.New("unix socket is not allowed")
+
+func init() {
+	ahp.RegisterDownloader(Scheme, Factory)
+	ahp.RegisterDownloader(TLSScheme, Factory)
+}
+
+// NewUnixFactory returns the factory of the downloaders connecting to the listed Unix sockets only.
+// Unlike TCP and TLS, the Unix transports are not registered on import: connecting to local sockets
+// must be enabled explicitly with ahp.RegisterDownloader(tcp.UnixScheme, tcp.NewUnixFactory(paths)).
+func NewUnixFactory(paths []string) ahp.DownloaderFactory {
+	allowed := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		allowed[cleanSocketPath(path)] = struct{}{}
+	}
+
+	return func(cfg ahp.DownloaderConfig) (ahp.Downloader, error) {
+		if _, ok := allowed[cleanSocketPath(cfg.Address)]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrSocketNotAllowed, cfg.Address)
+		}
+
+		return Factory(cfg)
+	}
+}
+
+// cleanSocketPath returns the shortest equivalent of the socket path, names of the Linux abstract
+// namespace starting with "@" are left as is.
+func cleanSocketPath(path string) string {
+	if strings.HasPrefix(path, "@") {
+		return path
+	}
+
+	return filepath.Clean(path)
+}
+
+// Factory builds a Downloader from the configuration resolved by ahp.DownloaderRegistry.
+func Factory(cfg ahp.DownloaderConfig) (ahp.Downloader, error) {
+	opts := []Option{
+		WithReadMode(cfg.ReadMode),
+		WithBuffering(cfg.Buffered),
+	}
+
+	if cfg.Framing != ahp.FramingNone {
+		framer, err := NewFramer(cfg.Framing, cfg.Delimiter)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, WithFramer(framer))
+	}
+
+	if len(cfg.RequestPayload) != 0 {
+		opts = append(opts, WithRequest(cfg.RequestPayload, cfg.WriteTimeout))
+	}
+
+	if cfg.Policy != nil {
+		opts = append(opts, WithPolicy(cfg.Policy))
+	}
+
+	if cfg.Scheme == UnixScheme || cfg.Scheme == UnixPacketScheme {
+		opts = append(opts, WithNetwork(cfg.Scheme))
+	}
+
+	if cfg.Scheme == TLSScheme {
+		tlsConfig := cfg.TLS
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+
+		opts = append(opts, WithTLS(tlsConfig))
+	}
+
+	return NewDownloader(cfg.Address, cfg.DialTimeout, opts...), nil
+}
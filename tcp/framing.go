@@ -0,0 +1,222 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	ahp "github.com/morozovcookie/afihtmlparser"
+)
+
+var (
+	ErrUnknownFraming = errors.New("unknown framing")
+	ErrEmptyDelimiter = errors.New("empty delimiter")
+)
+
+// Framer extracts the content from the connection stream.
+type Framer interface {
+	// Frame returns a reader over the content. The reader fails with io.ErrUnexpectedEOF when the
+	// stream ends before the content is complete and with ahp.ErrContentTooLarge when the content
+	// exceeds limit bytes.
+	Frame(r io.Reader, limit int64) (content io.Reader, err error)
+}
+
+// FixedLength frames exactly limit bytes of the stream.
+type FixedLength struct{}
+
+func (FixedLength) Frame(r io.Reader, limit int64) (io.Reader, error) {
+	return &exactReader{r: r, n: limit}, nil
+}
+
+// UntilEOF frames everything until the peer closes the connection.
+type UntilEOF struct{}
+
+func (UntilEOF) Frame(r io.Reader, limit int64) (io.Reader, error) {
+	return ahp.NewLimitedReader(r, limit, ahp.ErrContentTooLarge), nil
+}
+
+// LengthPrefix frames the content preceded by its big-endian length header.
+type LengthPrefix int
+
+const (
+	// VarintPrefix is an unsigned varint header as written by binary.PutUvarint.
+	VarintPrefix LengthPrefix = 0
+	// Uint16Prefix is a 2-byte header.
+	Uint16Prefix LengthPrefix = 2
+	// Uint32Prefix is a 4-byte header.
+	Uint32Prefix LengthPrefix = 4
+)
+
+func (p LengthPrefix) Frame(r io.Reader, limit int64) (io.Reader, error) {
+	length, r, err := p.readLength(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if length > uint64(limit) {
+		return nil, ahp.ErrContentTooLarge
+	}
+
+	return &exactReader{r: r, n: int64(length)}, nil
+}
+
+func (p LengthPrefix) readLength(r io.Reader) (length uint64, rest io.Reader, err error) {
+	switch p {
+	case VarintPrefix:
+		br := bufio.NewReader(r)
+
+		if length, err = binary.ReadUvarint(br); err != nil {
+			return 0, nil, unexpectedEOF(err)
+		}
+
+		return length, br, nil
+	case Uint16Prefix, Uint32Prefix:
+		header := make([]byte, p)
+
+		if _, err = io.ReadFull(r, header); err != nil {
+			return 0, nil, unexpectedEOF(err)
+		}
+
+		if p == Uint16Prefix {
+			return uint64(binary.BigEndian.Uint16(header)), r, nil
+		}
+
+		return uint64(binary.BigEndian.Uint32(header)), r, nil
+	}
+
+	return 0, nil, ErrUnknownFraming
+}
+
+// Delimiter frames the content terminated by the sentinel, e.g. "\x00" or "\r\n.\r\n". The
+// sentinel itself is not a part of the content.
+type Delimiter []byte
+
+func (d Delimiter) Frame(r io.Reader, limit int64) (io.Reader, error) {
+	if len(d) == 0 {
+		return nil, ErrEmptyDelimiter
+	}
+
+	return &delimitedReader{r: r, delim: d, n: limit}, nil
+}
+
+// NewFramer returns the Framer for the framing requested in the configuration.
+func NewFramer(framing ahp.Framing, delimiter []byte) (framer Framer, err error) {
+	switch framing {
+	case ahp.FramingNone:
+		return FixedLength{}, nil
+	case ahp.FramingUint16:
+		return Uint16Prefix, nil
+	case ahp.FramingUint32:
+		return Uint32Prefix, nil
+	case ahp.FramingVarint:
+		return VarintPrefix, nil
+	case ahp.FramingDelimiter:
+		return Delimiter(delimiter), nil
+	}
+
+	return nil, ErrUnknownFraming
+}
+
+type exactReader struct {
+	r io.Reader
+	n int64
+}
+
+func (er *exactReader) Read(p []byte) (n int, err error) {
+	if er.n <= 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > er.n {
+		p = p[:er.n]
+	}
+
+	n, err = er.r.Read(p)
+	er.n -= int64(n)
+
+	if err == io.EOF && er.n > 0 {
+		return n, io.ErrUnexpectedEOF
+	}
+
+	if err == io.EOF {
+		return n, nil
+	}
+
+	return n, err
+}
+
+type delimitedReader struct {
+	r     io.Reader
+	delim []byte
+	n     int64
+
+	buf  []byte
+	done bool
+	eof  bool
+}
+
+func (dr *delimitedReader) Read(p []byte) (n int, err error) {
+	for {
+		if dr.done && len(dr.buf) == 0 {
+			return 0, io.EOF
+		}
+
+		// Bytes that can't be a part of the delimiter are safe to hand out.
+		safe := len(dr.buf)
+		if !dr.done {
+			safe -= len(dr.delim) - 1
+		}
+
+		if safe > 0 {
+			if int64(safe) > dr.n {
+				return 0, ahp.ErrContentTooLarge
+			}
+
+			n = copy(p, dr.buf[:safe])
+			dr.buf = dr.buf[n:]
+			dr.n -= int64(n)
+
+			return n, nil
+		}
+
+		if dr.eof {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		if err = dr.fill(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (dr *delimitedReader) fill() (err error) {
+	chunk := make([]byte, 4096)
+
+	n, err := dr.r.Read(chunk)
+	dr.buf = append(dr.buf, chunk[:n]...)
+
+	if i := bytes.Index(dr.buf, dr.delim); i >= 0 {
+		dr.buf = dr.buf[:i]
+		dr.done = true
+
+		return nil
+	}
+
+	if err == io.EOF {
+		dr.eof = true
+
+		return nil
+	}
+
+	return err
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,48 @@
+package tcp
+
+import (
+	"io"
+)
+
+const (
+	// packetOverhead is the room left in the packet buffer for the framing headers and trailers.
+	packetOverhead = 64 << 10
+	// maxPacketSize bounds the packet buffer, a message can't exceed the socket send buffer anyway.
+	maxPacketSize = 16 << 20
+)
+
+// packetReader reads a message oriented connection, such as a "unixpacket" one, where every Read
+// receives a whole message and discards the part of it not fitting into the buffer. The messages
+// are read into a buffer large enough for the content and served from it in parts of any size.
+type packetReader struct {
+	r       io.Reader
+	buf     []byte
+	pending []byte
+}
+
+func newPacketReader(r io.Reader, limit int64) *packetReader {
+	size := limit + packetOverhead
+	if size > maxPacketSize {
+		size = maxPacketSize
+	}
+
+	return &packetReader{
+		r:   r,
+		buf: make([]byte, size),
+	}
+}
+
+func (r *packetReader) Read(p []byte) (n int, err error) {
+	if len(r.pending) == 0 {
+		if n, err = r.r.Read(r.buf); n == 0 {
+			return 0, err
+		}
+
+		r.pending = r.buf[:n]
+	}
+
+	n = copy(p, r.pending)
+	r.pending = r.pending[n:]
+
+	return n, nil
+}
@@ -0,0 +1,236 @@
+package xpath
+
+import (
+	"context"
+	"errors"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+	antchfx "github.com/antchfx/xpath"
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/morozovcookie/afihtmlparser/render"
+	"golang.org/x/net/html"
+)
+
+// ErrNotAttribute is returned in the attribute render mode when the expression selects other nodes.
+var ErrNotAttribute = errors.New("selected node is not an attribute")
+
+// document is a parsed HTML or XML document.
+type document interface {
+	ahp.Document
+
+	compile(expression string) (*antchfx.Expr, error)
+	navigator() antchfx.NodeNavigator
+}
+
+type htmlDocument struct {
+	root    *html.Node
+	mode    ahp.RenderMode
+	escaped bool
+}
+
+func (d *htmlDocument) compile(expression string) (*antchfx.Expr, error) {
+	return antchfx.Compile(expression)
+}
+
+func (d *htmlDocument) navigator() antchfx.NodeNavigator {
+	return htmlquery.CreateXPathNavigator(d.root)
+}
+
+func (d *htmlDocument) Evaluate(ctx context.Context, expression string) (result ahp.Result, err error) {
+	expr, err := d.compile(expression)
+	if err != nil {
+		return result, err
+	}
+
+	v := expr.Evaluate(d.navigator())
+
+	it, ok := v.(*antchfx.NodeIterator)
+	if !ok {
+		result.Value = scalar(v)
+
+		return result, nil
+	}
+
+	nn := htmlNodes(it)
+
+	result.Nodes = make([]string, 0, len(nn))
+
+	for _, n := range nn {
+		if err = ctx.Err(); err != nil {
+			return result, err
+		}
+
+		var node string
+		if node, err = d.render(n); err != nil {
+			return result, err
+		}
+
+		result.Nodes = append(result.Nodes, node)
+	}
+
+	return result, nil
+}
+
+func (d *htmlDocument) render(n *html.Node) (string, error) {
+	switch {
+	case d.mode == ahp.RenderModeAttribute:
+	case d.escaped:
+		return render.EscapedHTML(n, d.mode)
+	default:
+		return render.HTML(n, d.mode)
+	}
+
+	// htmlquery makes up the selected attributes as the elements without a parent named after them
+	// holding their values.
+	if n.Type != html.ElementNode || n.Parent != nil {
+		return "", ErrNotAttribute
+	}
+
+	return htmlquery.InnerText(n), nil
+}
+
+// htmlNodes returns the nodes selected by the iterator like htmlquery.QuerySelectorAll, without
+// evaluating the expression again.
+func htmlNodes(it *antchfx.NodeIterator) []*html.Node {
+	var nn []*html.Node
+
+	for it.MoveNext() {
+		nav, ok := it.Current().(*htmlquery.NodeNavigator)
+		if !ok {
+			continue
+		}
+
+		n := nav.Current()
+		if nav.NodeType() == antchfx.AttributeNode {
+			n = &html.Node{
+				Type: html.ElementNode,
+				Data: nav.LocalName(),
+			}
+
+			n.AppendChild(&html.Node{
+				Type: html.TextNode,
+				Data: nav.Value(),
+			})
+		}
+
+		// The same as htmlquery, skip the duplicates of the first node. Unlike htmlquery, the equal
+		// values of distinct attributes are kept.
+		if len(nn) > 0 && nn[0] == n {
+			continue
+		}
+
+		nn = append(nn, n)
+	}
+
+	return nn
+}
+
+type xmlDocument struct {
+	root       *xmlquery.Node
+	namespaces map[string]string
+	mode       ahp.RenderMode
+}
+
+func (d *xmlDocument) compile(expression string) (*antchfx.Expr, error) {
+	var namespaces map[string]string
+	if len(d.namespaces) != 0 {
+		namespaces = d.namespaces
+	}
+
+	return antchfx.CompileWithNS(expression, namespaces)
+}
+
+func (d *xmlDocument) navigator() antchfx.NodeNavigator {
+	return xmlquery.CreateXPathNavigator(d.root)
+}
+
+func (d *xmlDocument) Evaluate(ctx context.Context, expression string) (result ahp.Result, err error) {
+	expr, err := d.compile(expression)
+	if err != nil {
+		return result, err
+	}
+
+	v := expr.Evaluate(d.navigator())
+
+	it, ok := v.(*antchfx.NodeIterator)
+	if !ok {
+		result.Value = scalar(v)
+
+		return result, nil
+	}
+
+	nn := xmlNodes(it)
+
+	result.Nodes = make([]string, 0, len(nn))
+
+	for _, n := range nn {
+		if err = ctx.Err(); err != nil {
+			return result, err
+		}
+
+		var node string
+		if node, err = d.render(n); err != nil {
+			return result, err
+		}
+
+		result.Nodes = append(result.Nodes, node)
+	}
+
+	return result, nil
+}
+
+func (d *xmlDocument) render(n *xmlquery.Node) (string, error) {
+	attr := n.Type == xmlquery.AttributeNode
+
+	switch d.mode {
+	case ahp.RenderModeAttribute:
+		if !attr {
+			return "", ErrNotAttribute
+		}
+
+		return n.InnerText(), nil
+	case ahp.RenderModeText:
+		return render.Collapse(n.InnerText()), nil
+	}
+
+	if attr {
+		return n.InnerText(), nil
+	}
+
+	return n.OutputXML(d.mode != ahp.RenderModeInnerHTML), nil
+}
+
+// xmlNodes returns the nodes selected by the iterator like xmlquery.QuerySelectorAll, without
+// evaluating the expression again.
+func xmlNodes(it *antchfx.NodeIterator) []*xmlquery.Node {
+	var nn []*xmlquery.Node
+
+	for it.MoveNext() {
+		nav, ok := it.Current().(*xmlquery.NodeNavigator)
+		if !ok {
+			continue
+		}
+
+		n := nav.Current()
+		if nav.NodeType() == antchfx.AttributeNode {
+			n = &xmlquery.Node{
+				Parent: n,
+				Type:   xmlquery.AttributeNode,
+				Data:   nav.LocalName(),
+			}
+
+			text := &xmlquery.Node{
+				Parent: n,
+				Type:   xmlquery.TextNode,
+				Data:   nav.Value(),
+			}
+
+			n.FirstChild, n.LastChild = text, text
+		}
+
+		nn = append(nn, n)
+	}
+
+	return nn
+}
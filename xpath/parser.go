@@ -1,47 +1,198 @@
 package xpath
 
 import (
-	"bytes"
+	"context"
 	"io"
+	"math"
+	"strconv"
 
 	"github.com/antchfx/htmlquery"
-	"golang.org/x/net/html"
+	"github.com/antchfx/xmlquery"
+	ahp "github.com/morozovcookie/afihtmlparser"
+	"github.com/morozovcookie/afihtmlparser/charset"
 )
 
 type Parser struct {
 	expression string
+	label      string
+	xml        bool
+	namespaces map[string]string
+	mode       ahp.RenderMode
+	escaped    bool
+
+	charset string
+}
+
+type Option func(p *Parser)
+
+// WithCharset sets the charset of the documents. By default it's detected from the byte order
+// mark or the <meta> tag of every document.
+func WithCharset(label string) Option {
+	return func(p *Parser) {
+		p.label = label
+	}
+}
+
+// WithXML makes the parser treat the documents as XML: the case of the names and CDATA sections
+// are preserved and the nodes are rendered as XML. The prefixes of the expression are bound to the
+// namespace URIs, so they match regardless of the prefixes used by the document. The charset is
+// taken from the XML declaration.
+func WithXML(namespaces map[string]string) Option {
+	return func(p *Parser) {
+		p.xml = true
+		p.namespaces = namespaces
+	}
 }
 
-func NewParser(expression string) *Parser {
-	return &Parser{
+// WithRenderMode sets how the selected nodes are returned, ahp.RenderModeOuterHTML by default.
+func WithRenderMode(mode ahp.RenderMode) Option {
+	return func(p *Parser) {
+		p.mode = mode
+	}
+}
+
+// WithEscaping renders the HTML with render.EscapedHTML instead of render.HTML.
+func WithEscaping(escaped bool) Option {
+	return func(p *Parser) {
+		p.escaped = escaped
+	}
+}
+
+func NewParser(expression string, opts ...Option) *Parser {
+	p := &Parser{
 		expression: expression,
 	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Charset returns the canonical name of the charset the last parsed document was transcoded from.
+func (p *Parser) Charset() string {
+	return p.charset
 }
 
 func (p *Parser) Parse(r io.Reader) ([]string, error) {
-	n, err := htmlquery.Parse(r)
+	return p.ParseContext(context.Background(), r)
+}
+
+// ParseContext is Parse which stops reading the document and rendering the nodes once the
+// context is done. The document is transcoded to UTF-8 before parsing. A scalar result of the
+// expression is returned as the only node.
+func (p *Parser) ParseContext(ctx context.Context, r io.Reader) ([]string, error) {
+	result, err := p.EvaluateContext(ctx, r)
 	if err != nil {
 		return nil, err
 	}
 
-	nn, err := htmlquery.QueryAll(n, p.expression)
+	if result.Value != nil {
+		return []string{formatValue(result.Value)}, nil
+	}
+
+	return result.Nodes, nil
+}
+
+// EvaluateContext is ParseContext which returns the number, string or boolean the expression
+// evaluates to, e.g. count(//li) or string(//title), as the value of the result.
+func (p *Parser) EvaluateContext(ctx context.Context, r io.Reader) (result ahp.Result, err error) {
+	doc, err := p.ParseDocument(ctx, r)
+	if err != nil {
+		return result, err
+	}
+
+	return doc.Evaluate(ctx, p.expression)
+}
+
+// ParseDocument parses the document once for evaluating several expressions. The document is
+// transcoded to UTF-8 before parsing.
+func (p *Parser) ParseDocument(ctx context.Context, r io.Reader) (ahp.Document, error) {
+	return p.parseDocument(ctx, r)
+}
+
+// Extract parses the document and extracts the records described by the schema from it.
+func (p *Parser) Extract(ctx context.Context, r io.Reader, schema Schema) ([]map[string]interface{}, error) {
+	doc, err := p.parseDocument(ctx, r)
 	if err != nil {
 		return nil, err
 	}
 
-	var (
-		out  = make([]string, 0, len(nn))
-		nbuf = &bytes.Buffer{}
-	)
+	return extract(ctx, doc, schema)
+}
 
-	for _, n := range nn {
-		if err = html.Render(nbuf, n); err != nil {
+func (p *Parser) parseDocument(ctx context.Context, r io.Reader) (document, error) {
+	if p.xml {
+		doc, err := xmlquery.Parse(ahp.NewContextReader(ctx, r))
+		if err != nil {
 			return nil, err
 		}
 
-		out = append(out, html.UnescapeString(nbuf.String()))
-		nbuf.Reset()
+		p.charset = xmlCharset(doc)
+
+		return &xmlDocument{root: doc, namespaces: p.namespaces, mode: p.mode}, nil
+	}
+
+	r, name, err := charset.NewReader(ahp.NewContextReader(ctx, r), p.label)
+	if err != nil {
+		return nil, err
+	}
+
+	p.charset = name
+
+	doc, err := htmlquery.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &htmlDocument{root: doc, mode: p.mode, escaped: p.escaped}, nil
+}
+
+// scalar returns the value of the expression as is, except for the numbers which JSON can't
+// represent, which are returned in the form of the XPath string() function.
+func scalar(v interface{}) interface{} {
+	f, ok := v.(float64)
+	if !ok {
+		return v
+	}
+
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+
+	return f
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	}
+
+	return ""
+}
+
+// xmlCharset returns the canonical name of the encoding from the XML declaration.
+func xmlCharset(doc *xmlquery.Node) string {
+	for n := doc.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type != xmlquery.DeclarationNode || n.Data != "xml" {
+			continue
+		}
+
+		if e, name := charset.Lookup(n.SelectAttr("encoding")); e != nil {
+			return name
+		}
 	}
 
-	return out, nil
+	return charset.DefaultCharset
 }
@@ -2,9 +2,12 @@ package xpath
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"testing"
 
+	ahp "github.com/morozovcookie/afihtmlparser"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -35,6 +38,20 @@ func TestParser_Parse(t *testing.T) {
 				`<li>Make plain text</li>`,
 			},
 		},
+		{
+			name:    "scalar",
+			enabled: true,
+
+			r: bytes.NewBufferString(`<ul>
+				<li>Tag attributes</li>
+				<li>Make plain text</li>
+			</ul>`),
+			expression: `count(//ul/li)`,
+
+			expectedNodes: []string{
+				`2`,
+			},
+		},
 		{
 			name:    "query error",
 			enabled: true,
@@ -65,3 +82,388 @@ func TestParser_Parse(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_ParseContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewParser(`//ul/li`).ParseContext(ctx, bytes.NewBufferString(`<ul><li>Tag attributes</li></ul>`))
+
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestParser_EvaluateContext(t *testing.T) {
+	const document = `<html><head><title>List</title></head><body><ul>
+		<li>Tag attributes</li>
+		<li>Make plain text</li>
+	</ul></body></html>`
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		expression string
+		opts       []Option
+		document   string
+
+		wantErr bool
+
+		expectedResult ahp.Result
+	}{
+		{
+			name:    "count",
+			enabled: true,
+
+			expression: `count(//li)`,
+			document:   document,
+
+			expectedResult: ahp.Result{Value: float64(2)},
+		},
+		{
+			name:    "string",
+			enabled: true,
+
+			expression: `string(//title)`,
+			document:   document,
+
+			expectedResult: ahp.Result{Value: "List"},
+		},
+		{
+			name:    "boolean",
+			enabled: true,
+
+			expression: `boolean(//table)`,
+			document:   document,
+
+			expectedResult: ahp.Result{Value: false},
+		},
+		{
+			name:    "number",
+			enabled: true,
+
+			expression: `number("1.5") * 2`,
+			document:   document,
+
+			expectedResult: ahp.Result{Value: float64(3)},
+		},
+		{
+			name:    "not a number",
+			enabled: true,
+
+			expression: `number(//title)`,
+			document:   document,
+
+			expectedResult: ahp.Result{Value: "NaN"},
+		},
+		{
+			name:    "nodes",
+			enabled: true,
+
+			expression: `//title`,
+			document:   document,
+
+			expectedResult: ahp.Result{Nodes: []string{`<title>List</title>`}},
+		},
+		{
+			name:    "xml count",
+			enabled: true,
+
+			expression: `count(//a:entry)`,
+			opts: []Option{
+				WithXML(map[string]string{"a": "http://www.w3.org/2005/Atom"}),
+			},
+			document: `<feed xmlns="http://www.w3.org/2005/Atom"><entry/><entry/><entry/></feed>`,
+
+			expectedResult: ahp.Result{Value: float64(3)},
+		},
+		{
+			name:    "syntax error",
+			enabled: true,
+
+			expression: `count(//li`,
+			document:   document,
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actualResult, err := NewParser(test.expression, test.opts...).
+				EvaluateContext(context.Background(), bytes.NewBufferString(test.document))
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			assert.Equal(t, test.expectedResult, actualResult)
+		})
+	}
+}
+
+func TestParser_ParseRenderMode(t *testing.T) {
+	const (
+		document = `<ul>
+			<li><a href="/1">Tag
+				attributes</a></li>
+			<li><a href="/2">Make <b>plain</b> text</a></li>
+		</ul>`
+		feed = `<feed><entry id="1"><title>First</title></entry></feed>`
+	)
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		expression string
+		opts       []Option
+		document   string
+
+		wantErr bool
+
+		expectedNodes []string
+	}{
+		{
+			name:    "outer html",
+			enabled: true,
+
+			expression: `//li[2]/a`,
+			opts:       []Option{WithRenderMode(ahp.RenderModeOuterHTML)},
+			document:   document,
+
+			expectedNodes: []string{`<a href="/2">Make <b>plain</b> text</a>`},
+		},
+		{
+			name:    "inner html",
+			enabled: true,
+
+			expression: `//li[2]/a`,
+			opts:       []Option{WithRenderMode(ahp.RenderModeInnerHTML)},
+			document:   document,
+
+			expectedNodes: []string{`Make <b>plain</b> text`},
+		},
+		{
+			name:    "text",
+			enabled: true,
+
+			expression: `//li/a`,
+			opts:       []Option{WithRenderMode(ahp.RenderModeText)},
+			document:   document,
+
+			expectedNodes: []string{`Tag attributes`, `Make plain text`},
+		},
+		{
+			name:    "attribute",
+			enabled: true,
+
+			expression: `//li/a/@href`,
+			opts:       []Option{WithRenderMode(ahp.RenderModeAttribute)},
+			document:   document,
+
+			expectedNodes: []string{`/1`, `/2`},
+		},
+		{
+			name:    "repeated attribute values",
+			enabled: true,
+
+			expression: `//a/@class`,
+			opts:       []Option{WithRenderMode(ahp.RenderModeAttribute)},
+			document:   `<a class="x">1</a><a class="y">2</a><a class="x">3</a>`,
+
+			expectedNodes: []string{`x`, `y`, `x`},
+		},
+		{
+			name:    "attribute mode with element",
+			enabled: true,
+
+			expression: `//li/a`,
+			opts:       []Option{WithRenderMode(ahp.RenderModeAttribute)},
+			document:   document,
+
+			wantErr: true,
+		},
+		{
+			name:    "xml inner",
+			enabled: true,
+
+			expression: `//entry`,
+			opts:       []Option{WithXML(nil), WithRenderMode(ahp.RenderModeInnerHTML)},
+			document:   feed,
+
+			expectedNodes: []string{`<title>First</title>`},
+		},
+		{
+			name:    "xml attribute",
+			enabled: true,
+
+			expression: `//entry/@id`,
+			opts:       []Option{WithXML(nil), WithRenderMode(ahp.RenderModeAttribute)},
+			document:   feed,
+
+			expectedNodes: []string{`1`},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actualNodes, err := NewParser(test.expression, test.opts...).Parse(bytes.NewBufferString(test.document))
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if test.wantErr {
+				assert.True(t, errors.Is(err, ErrNotAttribute))
+
+				return
+			}
+
+			assert.Equal(t, test.expectedNodes, actualNodes)
+		})
+	}
+}
+
+func TestParser_ParseEscaping(t *testing.T) {
+	const document = `<ul><li title="&#34;quoted&#34;">&lt;script&gt;alert(1)&lt;/script&gt;</li></ul>`
+
+	actualNodes, err := NewParser(`//li`, WithEscaping(true)).Parse(bytes.NewBufferString(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{`<li title="&#34;quoted&#34;">&lt;script&gt;alert(1)&lt;/script&gt;</li>`}, actualNodes)
+
+	actualNodes, err = NewParser(`//li`, WithEscaping(true), WithRenderMode(ahp.RenderModeText)).
+		Parse(bytes.NewBufferString(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{`<script>alert(1)</script>`}, actualNodes)
+}
+
+func TestParser_ParseCharset(t *testing.T) {
+	p := NewParser(`//li`)
+
+	actualNodes, err := p.Parse(bytes.NewBufferString(
+		"<html><head><meta charset=\"windows-1251\"></head><body><li>\xcf\xf0\xe8\xe2\xe5\xf2</li></body></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{`<li>Привет</li>`}, actualNodes)
+	assert.Equal(t, "windows-1251", p.Charset())
+}
+
+func TestParser_ParseXML(t *testing.T) {
+	const feed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
+	<title>Feed</title>
+	<entry>
+		<title>First</title>
+		<media:thumbnail url="https://example.com/1.png"/>
+		<summary><![CDATA[<b>bold</b>]]></summary>
+	</entry>
+	<entry>
+		<title>Second</title>
+		<pubDate>2020-01-01</pubDate>
+	</entry>
+</feed>`
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		expression string
+		namespaces map[string]string
+
+		wantErr bool
+
+		expectedNodes []string
+	}{
+		{
+			name:    "namespace bound to another prefix",
+			enabled: true,
+
+			expression: `//a:entry/a:title`,
+			namespaces: map[string]string{
+				"a": "http://www.w3.org/2005/Atom",
+			},
+
+			expectedNodes: []string{
+				`<title>First</title>`,
+				`<title>Second</title>`,
+			},
+		},
+		{
+			name:    "prefixed element and attribute",
+			enabled: true,
+
+			expression: `//m:thumbnail/@url`,
+			namespaces: map[string]string{
+				"m": "http://search.yahoo.com/mrss/",
+			},
+
+			expectedNodes: []string{
+				`https://example.com/1.png`,
+			},
+		},
+		{
+			name:    "case and cdata are preserved",
+			enabled: true,
+
+			expression: `//a:entry[a:pubDate]/a:pubDate | //a:summary`,
+			namespaces: map[string]string{
+				"a": "http://www.w3.org/2005/Atom",
+			},
+
+			expectedNodes: []string{
+				`<pubDate>2020-01-01</pubDate>`,
+				`<summary><![CDATA[<b>bold</b>]]></summary>`,
+			},
+		},
+		{
+			name:    "unbound prefix",
+			enabled: true,
+
+			expression: `//x:entry`,
+			namespaces: map[string]string{
+				"a": "http://www.w3.org/2005/Atom",
+			},
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			p := NewParser(test.expression, WithXML(test.namespaces))
+
+			actualNodes, err := p.Parse(bytes.NewBufferString(feed))
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			assert.Equal(t, test.expectedNodes, actualNodes)
+			assert.Equal(t, "utf-8", p.Charset())
+		})
+	}
+}
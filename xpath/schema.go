@@ -0,0 +1,122 @@
+package xpath
+
+import (
+	"context"
+	"fmt"
+
+	antchfx "github.com/antchfx/xpath"
+)
+
+// Schema describes the records extracted from the document: an object for every node selected
+// by Root holding the fields evaluated relatively to that node.
+type Schema struct {
+	Root   string           `json:"root"`
+	Fields map[string]Field `json:"fields"`
+}
+
+// Field is a field of a record with an expression relative to the node of the record, e.g. .//h2
+// or .//a/@href. Without nested fields its value is the string value of the first selected node
+// or the number, string or boolean the expression evaluates to. With nested fields it's an object
+// holding them evaluated relatively to the first selected node. Array makes it a list of all the
+// selected nodes. A field selecting no nodes is null.
+type Field struct {
+	Expression string           `json:"expression"`
+	Array      bool             `json:"array"`
+	Fields     map[string]Field `json:"fields"`
+}
+
+type compiledField struct {
+	expr   *antchfx.Expr
+	array  bool
+	fields compiledFields
+}
+
+type compiledFields map[string]*compiledField
+
+func extract(ctx context.Context, doc document, schema Schema) (records []map[string]interface{}, err error) {
+	root, err := doc.compile(schema.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := compileFields(doc, schema.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	records = make([]map[string]interface{}, 0)
+
+	for iter := root.Select(doc.navigator()); iter.MoveNext(); {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		records = append(records, fields.record(iter.Current().Copy()))
+	}
+
+	return records, nil
+}
+
+func compileFields(doc document, fields map[string]Field) (compiled compiledFields, err error) {
+	compiled = make(compiledFields, len(fields))
+
+	for name, f := range fields {
+		cf := &compiledField{array: f.Array}
+
+		if cf.expr, err = doc.compile(f.Expression); err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+
+		if cf.fields, err = compileFields(doc, f.Fields); err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+
+		compiled[name] = cf
+	}
+
+	return compiled, nil
+}
+
+func (ff compiledFields) record(nav antchfx.NodeNavigator) map[string]interface{} {
+	record := make(map[string]interface{}, len(ff))
+
+	for name, f := range ff {
+		record[name] = f.value(nav)
+	}
+
+	return record
+}
+
+func (f *compiledField) value(nav antchfx.NodeNavigator) interface{} {
+	v := f.expr.Evaluate(nav.Copy())
+
+	iter, ok := v.(*antchfx.NodeIterator)
+	if !ok {
+		return scalar(v)
+	}
+
+	values := make([]interface{}, 0)
+
+	for iter.MoveNext() {
+		value := f.node(iter.Current().Copy())
+		if !f.array {
+			return value
+		}
+
+		values = append(values, value)
+	}
+
+	if !f.array {
+		return nil
+	}
+
+	return values
+}
+
+func (f *compiledField) node(nav antchfx.NodeNavigator) interface{} {
+	if len(f.fields) != 0 {
+		return f.fields.record(nav)
+	}
+
+	return nav.Value()
+}
@@ -0,0 +1,171 @@
+package xpath
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParser_Extract(t *testing.T) {
+	const document = `<html><body>
+		<div class="product">
+			<h2>Phone</h2>
+			<span class="price">100</span>
+			<a href="/phone">Details</a>
+			<ul><li>black</li><li>white</li></ul>
+			<div class="seller"><b>Shop</b><i>5</i></div>
+		</div>
+		<div class="product">
+			<h2>Case</h2>
+			<a href="/case">Details</a>
+			<ul></ul>
+		</div>
+	</body></html>`
+
+	tt := []struct {
+		name    string
+		enabled bool
+
+		schema Schema
+
+		wantErr bool
+
+		expectedRecords []map[string]interface{}
+	}{
+		{
+			name:    "pass",
+			enabled: true,
+
+			schema: Schema{
+				Root: `//div[@class='product']`,
+				Fields: map[string]Field{
+					"title": {Expression: `.//h2`},
+					"price": {Expression: `.//span[@class='price']`},
+					"count": {Expression: `count(.//li)`},
+					"link":  {Expression: `.//a/@href`},
+					"colors": {
+						Expression: `.//li`,
+						Array:      true,
+					},
+					"seller": {
+						Expression: `.//div[@class='seller']`,
+						Fields: map[string]Field{
+							"name":   {Expression: `b`},
+							"rating": {Expression: `i`},
+						},
+					},
+				},
+			},
+
+			expectedRecords: []map[string]interface{}{
+				{
+					"title":  "Phone",
+					"price":  "100",
+					"count":  float64(2),
+					"link":   "/phone",
+					"colors": []interface{}{"black", "white"},
+					"seller": map[string]interface{}{
+						"name":   "Shop",
+						"rating": "5",
+					},
+				},
+				{
+					"title":  "Case",
+					"price":  nil,
+					"count":  float64(0),
+					"link":   "/case",
+					"colors": []interface{}{},
+					"seller": nil,
+				},
+			},
+		},
+		{
+			name:    "array of objects",
+			enabled: true,
+
+			schema: Schema{
+				Root: `//div[@class='product'][1]`,
+				Fields: map[string]Field{
+					"colors": {
+						Expression: `.//li`,
+						Array:      true,
+						Fields: map[string]Field{
+							"name":     {Expression: `.`},
+							"position": {Expression: `count(preceding-sibling::li) + 1`},
+						},
+					},
+				},
+			},
+
+			expectedRecords: []map[string]interface{}{
+				{
+					"colors": []interface{}{
+						map[string]interface{}{"name": "black", "position": float64(1)},
+						map[string]interface{}{"name": "white", "position": float64(2)},
+					},
+				},
+			},
+		},
+		{
+			name:    "no records",
+			enabled: true,
+
+			schema: Schema{
+				Root: `//table`,
+			},
+
+			expectedRecords: []map[string]interface{}{},
+		},
+		{
+			name:    "field syntax error",
+			enabled: true,
+
+			schema: Schema{
+				Root: `//div[@class='product']`,
+				Fields: map[string]Field{
+					"title": {Expression: `.//h2[`},
+				},
+			},
+
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.enabled {
+				t.SkipNow()
+			}
+
+			actualRecords, err := NewParser("").Extract(context.Background(), bytes.NewBufferString(document),
+				test.schema)
+			if (err != nil) != test.wantErr {
+				t.Error(err)
+				t.FailNow()
+			}
+
+			assert.Equal(t, test.expectedRecords, actualRecords)
+		})
+	}
+}
+
+func TestParser_ExtractXML(t *testing.T) {
+	p := NewParser("", WithXML(map[string]string{"a": "http://www.w3.org/2005/Atom"}))
+
+	actualRecords, err := p.Extract(context.Background(), bytes.NewBufferString(
+		`<feed xmlns="http://www.w3.org/2005/Atom"><entry><title>First</title><link href="/1"/></entry></feed>`),
+		Schema{
+			Root: `//a:entry`,
+			Fields: map[string]Field{
+				"title": {Expression: `a:title`},
+				"link":  {Expression: `a:link/@href`},
+			},
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []map[string]interface{}{{"title": "First", "link": "/1"}}, actualRecords)
+}